@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay/bulk"
+)
+
+func bulkCommand(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet(e, "bulk", "<file>")
+	action := fs.String("action", "refund", "refund or void")
+	format := fs.String("format", "", "csv or jsonl, guessed from the file extension by default")
+	concurrency := fs.Int("concurrency", 4, "calls in flight")
+	rate := fs.Float64("rate", 5, "maximum calls per second, 0 for no limit")
+	checkpoint := fs.String("checkpoint", "", "resume file recording finished rows")
+	resendUnknown := fs.Bool("resend-unknown", false, "resend rows of the checkpoint whose outcome is unknown without looking them up first")
+	report := fs.String("report", "", "CSV report path, stdout by default")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+
+	// Rows read from stdin leave nothing to answer the confirmation prompt with.
+	if fs.Arg(0) == "-" && !g.yes && !g.dryRun {
+		return fmt.Errorf("reading rows from stdin requires -yes")
+	}
+	rows, err := readRows(e.stdin, fs.Arg(0), *format)
+	if err != nil {
+		return err
+	}
+	opts := bulk.Options{
+		Action:      bulk.Action(*action),
+		Concurrency: *concurrency,
+		Checkpoint:  *checkpoint,
+
+		ResendUnknown: *resendUnknown,
+	}
+	if *rate > 0 {
+		opts.Interval = time.Duration(float64(time.Second) / *rate)
+	}
+	if g.dryRun {
+		return output(e.stdout, g.output, &dryRun{Operation: "bulk " + *action, Target: fs.Arg(0), Request: rows})
+	}
+	if err := confirmAction(e, g, "Run %s on %d transactions?", *action, len(rows)); err != nil {
+		return err
+	}
+	c, err := newClient(e, g)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = e.stdout
+	if *report != "" {
+		f, err := os.Create(*report)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	results, runErr := bulk.Run(ctx, c, rows, opts)
+	if err := bulk.WriteReport(w, results); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return runErr
+	}
+	failed := 0
+	for _, r := range results {
+		if r.ReturnCode != "0000" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", failed, len(results))
+	}
+	return nil
+}
+
+func readRows(stdin io.Reader, path, format string) ([]*bulk.Row, error) {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if format == "" {
+		format = "csv"
+		if strings.HasSuffix(path, ".jsonl") || strings.HasSuffix(path, ".ndjson") {
+			format = "jsonl"
+		}
+	}
+	switch format {
+	case "csv":
+		return bulk.ReadCSV(r)
+	case "jsonl":
+		return bulk.ReadJSONL(r)
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+}
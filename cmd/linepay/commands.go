@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+type command func(ctx context.Context, e *env, args []string) error
+
+var commands = map[string]command{
+	"details":     details,
+	"status":      status,
+	"confirm":     confirm,
+	"capture":     capture,
+	"void":        void,
+	"refund":      refund,
+	"regkey":      regkey,
+	"preapproved": preapproved,
+	"bulk":        bulkCommand,
+}
+
+// globalFlags are accepted by every command.
+type globalFlags struct {
+	profile  string
+	sandbox  bool
+	endpoint string
+	output   string
+	dryRun   bool
+	yes      bool
+}
+
+func newFlagSet(e *env, name, args string) (*flag.FlagSet, *globalFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: linepay %s [flags] %s\n\nflags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	g := &globalFlags{}
+	fs.StringVar(&g.profile, "profile", "default", "config profile")
+	fs.BoolVar(&g.sandbox, "sandbox", false, "use the sandbox endpoint")
+	fs.StringVar(&g.endpoint, "endpoint", "", "API endpoint, overrides -sandbox")
+	fs.StringVar(&g.output, "output", "json", "output format: json or table")
+	fs.BoolVar(&g.dryRun, "dry-run", false, "print the request instead of sending it")
+	fs.BoolVar(&g.yes, "yes", false, "do not ask for confirmation")
+	return fs, g
+}
+
+// parse parses args and checks the number of positional arguments.
+func parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if nargs >= 0 && fs.NArg() != nargs {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func parseTransactionID(fs *flag.FlagSet, s string) (int64, error) {
+	id, err := linepay.ParseInt64(s)
+	if err != nil {
+		fs.Usage()
+		return 0, fmt.Errorf("invalid transactionId %q", s)
+	}
+	return id, nil
+}
+
+// dryRun is printed instead of calling the API when -dry-run is given.
+type dryRun struct {
+	Operation string      `json:"operation"`
+	Target    string      `json:"target,omitempty"`
+	Request   interface{} `json:"request,omitempty"`
+}
+
+// execute prints the dry run or calls the API, prints the response and
+// turns a non-success returnCode into an error.
+func execute(e *env, g *globalFlags, d *dryRun, call func(c *linepay.Client) (interface{}, string, string, error)) error {
+	if g.dryRun {
+		return output(e.stdout, g.output, d)
+	}
+	if err := checkOutput(g.output); err != nil {
+		return err
+	}
+	c, err := newClient(e, g)
+	if err != nil {
+		return err
+	}
+	resp, returnCode, returnMessage, err := call(c)
+	if err != nil {
+		return err
+	}
+	if err := output(e.stdout, g.output, resp); err != nil {
+		return err
+	}
+	return linepay.CheckReturnCode(returnCode, returnMessage)
+}
+
+func details(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet(e, "details", "[transactionId ...]")
+	orderIDs := fs.String("order-id", "", "comma separated orderIds")
+	fields := fs.String("fields", "", "TRANSACTION or ORDER")
+	if err := parse(fs, args, -1); err != nil {
+		return err
+	}
+	req := &linepay.PaymentDetailsRequest{Fields: *fields}
+	for _, arg := range fs.Args() {
+		id, err := parseTransactionID(fs, arg)
+		if err != nil {
+			return err
+		}
+		req.TransactionID = append(req.TransactionID, id)
+	}
+	if *orderIDs != "" {
+		req.OrderID = strings.Split(*orderIDs, ",")
+	}
+	if len(req.TransactionID) == 0 && len(req.OrderID) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	return execute(e, g, &dryRun{Operation: "details", Request: req}, func(c *linepay.Client) (interface{}, string, string, error) {
+		resp, _, err := c.PaymentDetails(ctx, req)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return resp, resp.ReturnCode, resp.ReturnMessage, nil
+	})
+}
+
+func status(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet(e, "status", "<transactionId>")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseTransactionID(fs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return execute(e, g, &dryRun{Operation: "status", Target: fs.Arg(0)}, func(c *linepay.Client) (interface{}, string, string, error) {
+		resp, _, err := c.CheckPaymentStatus(ctx, id, &linepay.CheckPaymentStatusRequest{})
+		if err != nil {
+			return nil, "", "", err
+		}
+		return resp, resp.ReturnCode, resp.ReturnMessage, nil
+	})
+}
+
+func confirm(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet(e, "confirm", "<transactionId>")
+	amount := fs.Int("amount", 0, "payment amount, required")
+	currency := fs.String("currency", "JPY", "payment currency")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	if *amount <= 0 {
+		fs.Usage()
+		return errUsage
+	}
+	id, err := parseTransactionID(fs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	req := &linepay.ConfirmRequest{Amount: *amount, Currency: linepay.Currency(*currency)}
+	d := &dryRun{Operation: "confirm", Target: fs.Arg(0), Request: req}
+	if !g.dryRun {
+		if err := confirmAction(e, g, "Confirm %d %s for transaction %d?", *amount, *currency, id); err != nil {
+			return err
+		}
+	}
+	return execute(e, g, d, func(c *linepay.Client) (interface{}, string, string, error) {
+		resp, _, err := c.Confirm(ctx, id, req)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return resp, resp.ReturnCode, resp.ReturnMessage, nil
+	})
+}
+
+func capture(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet(e, "capture", "<transactionId>")
+	amount := fs.Int("amount", 0, "capture amount, required")
+	currency := fs.String("currency", "JPY", "capture currency")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	if *amount <= 0 {
+		fs.Usage()
+		return errUsage
+	}
+	id, err := parseTransactionID(fs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	req := &linepay.CaptureRequest{Amount: *amount, Currency: linepay.Currency(*currency)}
+	d := &dryRun{Operation: "capture", Target: fs.Arg(0), Request: req}
+	if !g.dryRun {
+		if err := confirmAction(e, g, "Capture %d %s of transaction %d?", *amount, *currency, id); err != nil {
+			return err
+		}
+	}
+	return execute(e, g, d, func(c *linepay.Client) (interface{}, string, string, error) {
+		resp, _, err := c.Capture(ctx, id, req)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return resp, resp.ReturnCode, resp.ReturnMessage, nil
+	})
+}
+
+func void(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet(e, "void", "<transactionId>")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseTransactionID(fs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	d := &dryRun{Operation: "void", Target: fs.Arg(0)}
+	if !g.dryRun {
+		if err := confirmAction(e, g, "Void transaction %d?", id); err != nil {
+			return err
+		}
+	}
+	return execute(e, g, d, func(c *linepay.Client) (interface{}, string, string, error) {
+		resp, _, err := c.Void(ctx, id, &linepay.VoidRequest{})
+		if err != nil {
+			return nil, "", "", err
+		}
+		return resp, resp.ReturnCode, resp.ReturnMessage, nil
+	})
+}
+
+func refund(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet(e, "refund", "<transactionId>")
+	amount := fs.Int("amount", 0, "refund amount, omit for a full refund")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	if *amount < 0 {
+		fs.Usage()
+		return errUsage
+	}
+	id, err := parseTransactionID(fs, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	req := &linepay.RefundRequest{RefundAmount: *amount}
+	d := &dryRun{Operation: "refund", Target: fs.Arg(0), Request: req}
+	if !g.dryRun {
+		what := "the full amount"
+		if *amount > 0 {
+			what = fmt.Sprint(*amount)
+		}
+		if err := confirmAction(e, g, "Refund %s of transaction %d?", what, id); err != nil {
+			return err
+		}
+	}
+	return execute(e, g, d, func(c *linepay.Client) (interface{}, string, string, error) {
+		resp, _, err := c.Refund(ctx, id, req)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return resp, resp.ReturnCode, resp.ReturnMessage, nil
+	})
+}
+
+func regkey(ctx context.Context, e *env, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "check":
+			return regkeyCheck(ctx, e, args[1:])
+		case "expire":
+			return regkeyExpire(ctx, e, args[1:])
+		}
+	}
+	fmt.Fprintln(e.stderr, "usage: linepay regkey check|expire [flags] <regKey>")
+	return errUsage
+}
+
+func regkeyCheck(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet(e, "regkey check", "<regKey>")
+	creditCardAuth := fs.Bool("credit-card-auth", false, "verify the credit card with a minimum amount authorization")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	regKey := fs.Arg(0)
+	req := &linepay.CheckRegKeyRequest{}
+	if *creditCardAuth {
+		req.CreditCardAuth = linepay.Bool(true)
+	}
+	return execute(e, g, &dryRun{Operation: "regkey check", Target: regKey, Request: req}, func(c *linepay.Client) (interface{}, string, string, error) {
+		resp, _, err := c.CheckRegKey(ctx, regKey, req)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return resp, resp.ReturnCode, resp.ReturnMessage, nil
+	})
+}
+
+func regkeyExpire(ctx context.Context, e *env, args []string) error {
+	fs, g := newFlagSet(e, "regkey expire", "<regKey>")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	regKey := fs.Arg(0)
+	d := &dryRun{Operation: "regkey expire", Target: regKey}
+	if !g.dryRun {
+		if err := confirmAction(e, g, "Expire regKey %s? It cannot be used again", regKey); err != nil {
+			return err
+		}
+	}
+	return execute(e, g, d, func(c *linepay.Client) (interface{}, string, string, error) {
+		resp, _, err := c.ExpireRegKey(ctx, regKey, &linepay.ExpireRegKeyRequest{})
+		if err != nil {
+			return nil, "", "", err
+		}
+		return resp, resp.ReturnCode, resp.ReturnMessage, nil
+	})
+}
+
+func preapproved(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 || args[0] != "pay" {
+		fmt.Fprintln(e.stderr, "usage: linepay preapproved pay [flags] <regKey>")
+		return errUsage
+	}
+	fs, g := newFlagSet(e, "preapproved pay", "<regKey>")
+	productName := fs.String("product", "", "product name")
+	amount := fs.Int("amount", 0, "payment amount, required")
+	currency := fs.String("currency", "JPY", "payment currency")
+	orderID := fs.String("order-id", "", "orderId, unique per payment")
+	authorizeOnly := fs.Bool("authorize-only", false, "authorize without capturing")
+	if err := parse(fs, args[1:], 1); err != nil {
+		return err
+	}
+	if *productName == "" || *orderID == "" || *amount <= 0 {
+		fs.Usage()
+		return errUsage
+	}
+	regKey := fs.Arg(0)
+	req := &linepay.PayPreapprovedRequest{
+		ProductName: *productName,
+		Amount:      *amount,
+		Currency:    linepay.Currency(*currency),
+		OrderID:     *orderID,
+	}
+	if *authorizeOnly {
+		req.Capture = linepay.Bool(false)
+	}
+	d := &dryRun{Operation: "preapproved pay", Target: regKey, Request: req}
+	if !g.dryRun {
+		if err := confirmAction(e, g, "Charge %d %s to regKey %s?", *amount, *currency, regKey); err != nil {
+			return err
+		}
+	}
+	return execute(e, g, d, func(c *linepay.Client) (interface{}, string, string, error) {
+		resp, _, err := c.PayPreapproved(ctx, regKey, req)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return resp, resp.ReturnCode, resp.ReturnMessage, nil
+	})
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// profile type
+type profile struct {
+	ChannelID     string `json:"channelId"`
+	ChannelSecret string `json:"channelSecret"`
+	Sandbox       bool   `json:"sandbox,omitempty"`
+	Endpoint      string `json:"endpoint,omitempty"`
+}
+
+// config type
+type config struct {
+	Profiles map[string]*profile `json:"profiles"`
+}
+
+func configPath(e *env) string {
+	if p := e.getenv("LINE_PAY_CONFIG"); p != "" {
+		return p
+	}
+	if dir := e.getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "linepay", "config.json")
+	}
+	return filepath.Join(e.getenv("HOME"), ".config", "linepay", "config.json")
+}
+
+// loadProfile returns the named profile with LINE_PAY_CHANNEL_ID and
+// LINE_PAY_CHANNEL_SECRET applied on top. A missing config file is not an
+// error unless a profile other than "default" is requested.
+func loadProfile(e *env, name string) (*profile, error) {
+	p := &profile{}
+	b, err := ioutil.ReadFile(configPath(e))
+	switch {
+	case err == nil:
+		c := new(config)
+		if err := json.Unmarshal(b, c); err != nil {
+			return nil, fmt.Errorf("config %s: %v", configPath(e), err)
+		}
+		found, ok := c.Profiles[name]
+		if !ok && name != "default" {
+			return nil, fmt.Errorf("profile %q not found in %s", name, configPath(e))
+		}
+		if ok {
+			p = found
+		}
+	case os.IsNotExist(err):
+		if name != "default" {
+			return nil, fmt.Errorf("profile %q requested but %s does not exist", name, configPath(e))
+		}
+	default:
+		return nil, err
+	}
+	if v := e.getenv("LINE_PAY_CHANNEL_ID"); v != "" {
+		p.ChannelID = v
+	}
+	if v := e.getenv("LINE_PAY_CHANNEL_SECRET"); v != "" {
+		p.ChannelSecret = v
+	}
+	return p, nil
+}
@@ -0,0 +1,139 @@
+// Command linepay operates on LINE Pay payments from the command line.
+//
+// Usage:
+//
+//	linepay <command> [flags] [arguments]
+//
+// Commands:
+//
+//	details [-order-id id,...] [-fields f] [transactionId ...]
+//	status <transactionId>
+//	confirm -amount n -currency c <transactionId>
+//	capture -amount n -currency c <transactionId>
+//	void <transactionId>
+//	refund [-amount n] <transactionId>
+//	regkey check [-credit-card-auth] <regKey>
+//	regkey expire <regKey>
+//	preapproved pay -product name -amount n -currency c -order-id id <regKey>
+//	bulk [-action refund|void] [-concurrency n] [-rate n] [-checkpoint f] [-report f] <file.csv|file.jsonl>
+//
+// Credentials are read from LINE_PAY_CHANNEL_ID and LINE_PAY_CHANNEL_SECRET,
+// or from a profile in the config file ($LINE_PAY_CONFIG, default
+// ~/.config/linepay/config.json):
+//
+//	{"profiles": {"default": {"channelId": "...", "channelSecret": "...", "sandbox": true}}}
+//
+// Every command accepts -profile, -sandbox, -endpoint, -output json|table,
+// -dry-run and -yes. Commands that move money or expire a regKey ask for
+// confirmation unless -yes is given.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+const usage = `usage: linepay <command> [flags] [arguments]
+
+commands:
+  details      look up transactions by transactionId or -order-id
+  status       check the payment status of a request
+  confirm      confirm a payment
+  capture      capture an authorization
+  void         void an authorization
+  refund       refund a captured payment
+  regkey       check or expire a regKey
+  preapproved  pay with a regKey
+  bulk         refund or void the transactions listed in a CSV or JSONL file
+
+run "linepay <command> -h" for the flags of a command
+`
+
+// errUsage reports a usage error that has already been printed.
+var errUsage = errors.New("usage")
+
+// env is the environment of one invocation.
+type env struct {
+	getenv func(string) string
+	stdin  *bufio.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	e := &env{
+		getenv: os.Getenv,
+		stdin:  bufio.NewReader(os.Stdin),
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+	os.Exit(run(context.Background(), e, os.Args[1:]))
+}
+
+// run executes the command in args and returns the exit status.
+func run(ctx context.Context, e *env, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(e.stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "linepay: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+	err := cmd(ctx, e, args[1:])
+	switch {
+	case err == nil:
+		return 0
+	case err == errUsage, err == flag.ErrHelp:
+		return 2
+	default:
+		fmt.Fprintf(e.stderr, "linepay: %v\n", err)
+		return 1
+	}
+}
+
+// confirmAction asks the user to confirm a money-moving action unless -yes was given.
+func confirmAction(e *env, g *globalFlags, format string, args ...interface{}) error {
+	if g.yes {
+		return nil
+	}
+	fmt.Fprintf(e.stderr, format+" [y/N]: ", args...)
+	answer, err := e.stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errors.New("aborted")
+}
+
+// newClient builds a client from the selected profile, the environment and the flags.
+func newClient(e *env, g *globalFlags) (*linepay.Client, error) {
+	p, err := loadProfile(e, g.profile)
+	if err != nil {
+		return nil, err
+	}
+	var options []linepay.ClientOption
+	switch {
+	case g.endpoint != "":
+		options = append(options, linepay.WithEndpoint(g.endpoint))
+	case g.sandbox:
+		options = append(options, linepay.WithSandbox())
+	case p.Endpoint != "":
+		options = append(options, linepay.WithEndpoint(p.Endpoint))
+	case p.Sandbox:
+		options = append(options, linepay.WithSandbox())
+	}
+	return linepay.New(p.ChannelID, p.ChannelSecret, options...)
+}
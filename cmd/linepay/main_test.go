@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setup(stdin string, vars map[string]string) (*env, *bytes.Buffer, *bytes.Buffer) {
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	e := &env{
+		getenv: func(k string) string { return vars[k] },
+		stdin:  bufio.NewReader(strings.NewReader(stdin)),
+		stdout: stdout,
+		stderr: stderr,
+	}
+	return e, stdout, stderr
+}
+
+func TestRun_Refund(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	calls := 0
+	mux.HandleFunc("/v3/payments/2019051300000000000/refund", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := ioutil.ReadAll(r.Body)
+		if got, want := string(body), `{"refundAmount":100}`; got != want {
+			t.Errorf("Request body = %s, want %s", got, want)
+		}
+		fmt.Fprint(w, `{"returnCode":"0000","info":{"refundTransactionId":2019051300000000001}}`)
+	})
+
+	vars := map[string]string{"LINE_PAY_CHANNEL_ID": "testid", "LINE_PAY_CHANNEL_SECRET": "testsecret"}
+	args := []string{"refund", "-endpoint", server.URL, "-amount", "100", "-output", "table", "2019051300000000000"}
+
+	e, _, _ := setup("n\n", vars)
+	if code := run(context.Background(), e, args); code != 1 || calls != 0 {
+		t.Fatalf("declined refund exited %d with %d calls; want 1 and 0", code, calls)
+	}
+
+	e, stdout, stderr := setup("y\n", vars)
+	if code := run(context.Background(), e, args); code != 0 {
+		t.Fatalf("refund exited %d: %s", code, stderr)
+	}
+	if calls != 1 {
+		t.Errorf("refund called %d times; want 1", calls)
+	}
+	if !strings.Contains(stdout.String(), "info.refundTransactionId    2019051300000000001") {
+		t.Errorf("table output:\n%s", stdout)
+	}
+}
+
+func TestRun_DryRun(t *testing.T) {
+	e, stdout, _ := setup("", nil)
+	code := run(context.Background(), e, []string{"void", "-dry-run", "123"})
+	if code != 0 {
+		t.Fatalf("exited %d", code)
+	}
+	if !strings.Contains(stdout.String(), `"operation": "void"`) {
+		t.Errorf("dry run output:\n%s", stdout)
+	}
+}
+
+func TestRun_ReturnCodeFails(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/v3/payments/requests/1/check", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"1150","returnMessage":"not found"}`)
+	})
+
+	dir, err := ioutil.TempDir("", "linepay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "config.json")
+	config := fmt.Sprintf(`{"profiles":{"shop":{"channelId":"testid","channelSecret":"testsecret","endpoint":%q}}}`, server.URL)
+	if err := ioutil.WriteFile(configFile, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	e, _, stderr := setup("", map[string]string{"LINE_PAY_CONFIG": configFile})
+	if code := run(context.Background(), e, []string{"status", "-profile", "shop", "1"}); code != 1 {
+		t.Errorf("exited %d; want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "1150") {
+		t.Errorf("stderr %q; want returnCode", stderr)
+	}
+}
+
+func TestRun_AmountRequired(t *testing.T) {
+	for _, args := range [][]string{
+		{"confirm", "-yes", "1"},
+		{"capture", "-yes", "-amount", "-1", "1"},
+		{"preapproved", "pay", "-yes", "-product", "p", "-order-id", "o", "rk"},
+		{"refund", "-yes", "-amount", "-5", "1"},
+	} {
+		e, _, _ := setup("y\n", map[string]string{"LINE_PAY_CHANNEL_ID": "testid", "LINE_PAY_CHANNEL_SECRET": "testsecret"})
+		if code := run(context.Background(), e, args); code != 2 {
+			t.Errorf("%v exited %d; want 2", args, code)
+		}
+	}
+}
+
+func TestRun_BulkStdin(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	calls := 0
+	mux.HandleFunc("/v3/payments/authorizations/1/void", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	vars := map[string]string{"LINE_PAY_CHANNEL_ID": "testid", "LINE_PAY_CHANNEL_SECRET": "testsecret"}
+	args := []string{"bulk", "-endpoint", server.URL, "-action", "void", "-rate", "0", "-"}
+
+	e, _, stderr := setup("transactionId\n1\n", vars)
+	if code := run(context.Background(), e, args); code != 1 || calls != 0 {
+		t.Fatalf("bulk from stdin without -yes exited %d with %d calls; want 1 and 0", code, calls)
+	}
+	if !strings.Contains(stderr.String(), "-yes") {
+		t.Errorf("stderr %q; want a hint about -yes", stderr)
+	}
+
+	e, _, stderr = setup("transactionId\n1\n", vars)
+	if code := run(context.Background(), e, append(args[:len(args)-1:len(args)-1], "-yes", "-")); code != 0 {
+		t.Fatalf("bulk exited %d: %s", code, stderr)
+	}
+	if calls != 1 {
+		t.Errorf("void called %d times; want 1", calls)
+	}
+}
+
+func TestRun_UnknownOutputNotSent(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	calls := 0
+	mux.HandleFunc("/v3/payments/1/refund", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	vars := map[string]string{"LINE_PAY_CHANNEL_ID": "testid", "LINE_PAY_CHANNEL_SECRET": "testsecret"}
+	e, _, stderr := setup("", vars)
+	if code := run(context.Background(), e, []string{"refund", "-endpoint", server.URL, "-yes", "-output", "xml", "1"}); code != 1 {
+		t.Errorf("exited %d; want 1", code)
+	}
+	if calls != 0 {
+		t.Errorf("refund called %d times; want 0", calls)
+	}
+	if !strings.Contains(stderr.String(), "xml") {
+		t.Errorf("stderr %q; want the unknown format", stderr)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+)
+
+// output writes v as indented JSON or as a two column table of flattened fields.
+// checkOutput fails for a format output does not know, so that it can be checked before calling the API.
+func checkOutput(format string) error {
+	switch format {
+	case "json", "table":
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func output(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "table":
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		// UseNumber keeps transactionIds above 2^53 intact
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var tree interface{}
+		if err := dec.Decode(&tree); err != nil {
+			return err
+		}
+		rows := make(map[string]string)
+		flatten("", tree, rows)
+		keys := make([]string, 0, len(rows))
+		for k := range rows {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, k := range keys {
+			fmt.Fprintf(tw, "%s\t%s\n", k, rows[k])
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func flatten(prefix string, v interface{}, rows map[string]string) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			flatten(join(k), child, rows)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(join(strconv.Itoa(i)), child, rows)
+		}
+	case nil:
+		rows[prefix] = ""
+	default:
+		b, _ := json.Marshal(v)
+		s := string(b)
+		if str, ok := v.(string); ok {
+			s = str
+		}
+		rows[prefix] = s
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/gob"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -13,27 +14,21 @@ import (
 	"github.com/gorilla/sessions"
 
 	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay/flow"
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay/vault"
 )
 
 var (
 	store = sessions.NewCookieStore([]byte(os.Getenv("SESSION_KEY")))
 )
 
-// PaymentTransactionSession type
-type PaymentTransactionSession struct {
-	TransactionID int64  `json:"transactionId"`
-	OrderID       string `json:"orderId"`
-	Amount        int    `json:"amount"`
-	Currency      string `json:"currency"`
-}
-
 // UserSession type
+// The regKey itself is kept in the vault, never in the cookie.
 type UserSession struct {
-	RegKey string `json:"regKey"`
+	ID string `json:"id"`
 }
 
 func init() {
-	gob.Register(&PaymentTransactionSession{})
 	gob.Register(&UserSession{})
 }
 
@@ -46,8 +41,24 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	http.HandleFunc("/pay/request", func(w http.ResponseWriter, r *http.Request) {
-		reserveReq := &linepay.RequestRequest{
+	vaultKey, err := hex.DecodeString(os.Getenv("REGKEY_VAULT_KEY"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	keys, err := vault.NewStaticKeyProvider("1", vaultKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	backend, err := vault.NewFileBackend(os.Getenv("REGKEY_VAULT_DIR"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	regKeys, err := vault.NewEncryptedStore(backend, keys)
+	if err != nil {
+		log.Fatal(err)
+	}
+	buildOrder := func(r *http.Request) (*linepay.RequestRequest, error) {
+		return &linepay.RequestRequest{
 			Amount:   250,
 			Currency: "JPY",
 			OrderID:  uuid.New().String(),
@@ -75,90 +86,63 @@ func main() {
 					PayType: "PREAPPROVED",
 				},
 			},
-		}
-		requestResp, _, err := pay.Request(context.Background(), reserveReq)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if requestResp.ReturnCode != "0000" {
-			http.Error(w, requestResp.ReturnMessage, http.StatusInternalServerError)
-			return
-		}
-		transactionID := requestResp.Info.TransactionID
-		paymentSession, err := store.Get(r, "payment-transaction")
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		paymentSession.Values[transactionID] = &PaymentTransactionSession{
-			TransactionID: transactionID,
-			OrderID:       reserveReq.OrderID,
-			Amount:        reserveReq.Amount,
-			Currency:      reserveReq.Currency,
-		}
-		if err := paymentSession.Save(r, w); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		http.Redirect(w, r, requestResp.Info.PaymentURL.Web, http.StatusFound)
-	})
-	http.HandleFunc("/pay/confirm", func(w http.ResponseWriter, r *http.Request) {
-		transactionID, err := linepay.ParseInt64(r.URL.Query().Get("transactionId"))
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		paymentSession, err := store.Get(r, "payment-transaction")
+		}, nil
+	}
+	fulfill := func(w http.ResponseWriter, r *http.Request, tx *flow.Transaction, confirmResp *linepay.ConfirmResponse) {
+		userSession, err := store.Get(r, "user")
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		transactionVal, ok := paymentSession.Values[transactionID]
-		if !ok {
-			http.Error(w, fmt.Sprintf("PaymentTransaction is not found. id:%d", transactionID), http.StatusInternalServerError)
-			return
-		}
-		transaction := transactionVal.(*PaymentTransactionSession)
-		confirmReq := &linepay.ConfirmRequest{
-			Amount:   transaction.Amount,
-			Currency: transaction.Currency,
+		user := &UserSession{
+			ID: uuid.New().String(),
 		}
-		confirmResp, _, err := pay.Confirm(context.Background(), transactionID, confirmReq)
-		if err != nil {
+		if err := regKeys.Put(r.Context(), user.ID, confirmResp.Info.RegKey); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if confirmResp.ReturnCode != "0000" {
-			http.Error(w, confirmResp.ReturnMessage, http.StatusInternalServerError)
-			return
-		}
-		paymentSession.Values["user"] = &UserSession{
-			RegKey: confirmResp.Info.RegKey,
-		}
-		if err := paymentSession.Save(r, w); err != nil {
+		userSession.Values["user"] = user
+		if err := userSession.Save(r, w); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		// The regKey stays on the server; never send it to the browser.
+		resp := *confirmResp
+		resp.Info.RegKey = ""
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(confirmResp)
-	})
+		json.NewEncoder(w).Encode(resp)
+	}
+	payment, err := flow.New(
+		pay,
+		flow.NewSessionStore(store, "payment-transaction"),
+		buildOrder,
+		flow.WithSuccessFunc(fulfill),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	http.Handle("/pay/", http.StripPrefix("/pay", payment))
 	http.HandleFunc("/pay/regKey", func(w http.ResponseWriter, r *http.Request) {
-		paymentSession, err := store.Get(r, "payment-transaction")
+		userSession, err := store.Get(r, "user")
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		userVal, ok := paymentSession.Values["user"]
+		userVal, ok := userSession.Values["user"]
 		if !ok {
 			http.Error(w, "UserTransaction is not found", http.StatusInternalServerError)
 			return
 		}
 		user := userVal.(*UserSession)
+		regKey, err := regKeys.Get(r.Context(), user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		payPreapprovedResp, _, err := pay.PayPreapproved(
 			context.Background(),
-			user.RegKey,
+			regKey,
 			&linepay.PayPreapprovedRequest{
 				ProductName: "Prime MemberShip",
 				Amount:      250,
@@ -1,8 +1,6 @@
 package main
 
 import (
-	"context"
-	"encoding/gob"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -10,27 +8,11 @@ import (
 	"os"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/sessions"
 
 	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay/flow"
 )
 
-var (
-	store = sessions.NewCookieStore([]byte(os.Getenv("SESSION_KEY")))
-)
-
-// PaymentTransactionSession type
-type PaymentTransactionSession struct {
-	TransactionID int64  `json:"transactionId"`
-	OrderID       string `json:"orderId"`
-	Amount        int    `json:"amount"`
-	Currency      string `json:"currency"`
-}
-
-func init() {
-	gob.Register(&PaymentTransactionSession{})
-}
-
 func main() {
 	pay, err := linepay.New(
 		os.Getenv("LINE_PAY_CHANNEL_ID"),
@@ -40,8 +22,8 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	http.HandleFunc("/pay/request", func(w http.ResponseWriter, r *http.Request) {
-		requestReq := &linepay.RequestRequest{
+	buildOrder := func(r *http.Request) (*linepay.RequestRequest, error) {
+		return &linepay.RequestRequest{
 			Amount:   250,
 			Currency: "JPY",
 			OrderID:  uuid.New().String(),
@@ -64,69 +46,23 @@ func main() {
 				ConfirmURL: os.Getenv("LINE_PAY_CONFIRM_URL"),
 				CancelURL:  os.Getenv("LINE_PAY_CANCEL_URL"),
 			},
-		}
-		requestResp, _, err := pay.Request(context.Background(), requestReq)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if requestResp.ReturnCode != "0000" {
-			http.Error(w, requestResp.ReturnMessage, http.StatusInternalServerError)
-			return
-		}
-		transactionID := requestResp.Info.TransactionID
-		session, err := store.Get(r, "payment-transaction")
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		session.Values[transactionID] = &PaymentTransactionSession{
-			TransactionID: transactionID,
-			OrderID:       requestReq.OrderID,
-			Amount:        requestReq.Amount,
-			Currency:      requestReq.Currency,
-		}
-		if err := session.Save(r, w); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		http.Redirect(w, r, requestResp.Info.PaymentURL.Web, http.StatusFound)
-	})
-
-	http.HandleFunc("/pay/confirm", func(w http.ResponseWriter, r *http.Request) {
-		transactionID, err := linepay.ParseInt64(r.URL.Query().Get("transactionId"))
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		session, err := store.Get(r, "payment-transaction")
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		transactionVal, ok := session.Values[transactionID]
-		if !ok {
-			http.Error(w, fmt.Sprintf("PaymentTransaction is not found. id:%d", transactionID), http.StatusInternalServerError)
-			return
-		}
-		transaction := transactionVal.(*PaymentTransactionSession)
-		confirmReq := &linepay.ConfirmRequest{
-			Amount:   transaction.Amount,
-			Currency: transaction.Currency,
-		}
-		confirmResp, _, err := pay.Confirm(context.Background(), transactionID, confirmReq)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		if confirmResp.ReturnCode != "0000" {
-			http.Error(w, confirmResp.ReturnMessage, http.StatusInternalServerError)
-			return
-		}
+		}, nil
+	}
+	fulfill := func(w http.ResponseWriter, r *http.Request, tx *flow.Transaction, confirmResp *linepay.ConfirmResponse) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(confirmResp)
-	})
+	}
+	payment, err := flow.New(
+		pay,
+		flow.NewCookieStore([]byte(os.Getenv("SESSION_KEY"))),
+		buildOrder,
+		flow.WithSuccessFunc(fulfill),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	http.Handle("/pay/", http.StripPrefix("/pay", payment))
 	fmt.Println("open http://localhost:8080/pay/request")
 	if err := http.ListenAndServe(":8080", nil); err != nil {
 		log.Fatal(err)
@@ -1,9 +1,11 @@
 module github.com/gotokatsuya/line-pay-sdk-go
 
-go 1.14
+go 1.18
 
 require (
 	github.com/google/go-querystring v1.0.0
 	github.com/google/uuid v1.2.0
 	github.com/gorilla/sessions v1.2.1
 )
+
+require github.com/gorilla/securecookie v1.1.1 // indirect
@@ -0,0 +1,350 @@
+// Package authz tracks LINE Pay authorizations created with
+// options.payment.capture=false and captures, voids or reports them before
+// their authorizationExpireDate passes.
+package authz
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+// Policy type
+// Policy decides what the tracker does with an authorization that is about to expire.
+type Policy string
+
+// Policy constants
+const (
+	PolicyCapture Policy = "CAPTURE"
+	PolicyVoid    Policy = "VOID"
+	PolicyAlert   Policy = "ALERT"
+)
+
+// Status type
+type Status string
+
+// Status constants
+const (
+	StatusPending  Status = "PENDING"
+	StatusCaptured Status = "CAPTURED"
+	StatusVoided   Status = "VOIDED"
+	StatusAlerted  Status = "ALERTED"
+	StatusExpired  Status = "EXPIRED"
+)
+
+// Authorization type
+type Authorization struct {
+	TransactionID int64            `json:"transactionId"`
+	OrderID       string           `json:"orderId"`
+	Amount        int              `json:"amount"`
+	Currency      linepay.Currency `json:"currency"`
+	ExpireAt      time.Time        `json:"expireAt"`
+	Policy        Policy           `json:"policy"`
+	Status        Status           `json:"status"`
+	Attempts      int              `json:"attempts"`
+	LastError     string           `json:"lastError,omitempty"`
+	UpdatedAt     time.Time        `json:"updatedAt"`
+}
+
+// AlertFunc type
+// AlertFunc is called for PolicyAlert authorizations, for failed capture/void attempts and for lapsed authorizations.
+type AlertFunc func(ctx context.Context, a *Authorization)
+
+// ErrorFunc type
+// ErrorFunc is called by Run for every error of RunOnce.
+type ErrorFunc func(ctx context.Context, err error)
+
+// ErrNoExpireDate is returned by Register when the confirm response carries no authorizationExpireDate.
+var ErrNoExpireDate = errors.New("authz: missing authorizationExpireDate")
+
+// Tracker type
+type Tracker struct {
+	client   *linepay.Client
+	store    Store
+	policy   Policy
+	leadTime time.Duration
+	interval time.Duration
+	alert    AlertFunc
+	onError  ErrorFunc
+	now      func() time.Time
+}
+
+// Option type
+type Option func(*Tracker) error
+
+// New returns a new tracker instance.
+func New(client *linepay.Client, store Store, options ...Option) (*Tracker, error) {
+	if client == nil {
+		return nil, errors.New("missing client")
+	}
+	if store == nil {
+		return nil, errors.New("missing store")
+	}
+	t := &Tracker{
+		client:   client,
+		store:    store,
+		policy:   PolicyVoid,
+		leadTime: 24 * time.Hour,
+		interval: time.Minute,
+		onError:  logError,
+		now:      time.Now,
+	}
+	for _, option := range options {
+		err := option(t)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// WithPolicy function
+// WithPolicy sets the policy used by Register. Defaults to PolicyVoid.
+func WithPolicy(p Policy) Option {
+	return func(t *Tracker) error {
+		switch p {
+		case PolicyCapture, PolicyVoid, PolicyAlert:
+		default:
+			return errors.New("authz: unknown policy " + string(p))
+		}
+		t.policy = p
+		return nil
+	}
+}
+
+// WithLeadTime function
+// WithLeadTime sets how long before expiry the policy is triggered. Defaults to 24 hours.
+func WithLeadTime(d time.Duration) Option {
+	return func(t *Tracker) error {
+		t.leadTime = d
+		return nil
+	}
+}
+
+// WithInterval function
+// WithInterval sets how often Run polls the store. Defaults to one minute.
+func WithInterval(d time.Duration) Option {
+	return func(t *Tracker) error {
+		if d <= 0 {
+			return errors.New("authz: interval must be positive")
+		}
+		t.interval = d
+		return nil
+	}
+}
+
+// WithAlertFunc function
+func WithAlertFunc(f AlertFunc) Option {
+	return func(t *Tracker) error {
+		t.alert = f
+		return nil
+	}
+}
+
+// WithErrorFunc function
+// WithErrorFunc sets what Run does with store and lookup errors. Defaults to logging them.
+func WithErrorFunc(f ErrorFunc) Option {
+	return func(t *Tracker) error {
+		t.onError = f
+		return nil
+	}
+}
+
+// WithClock function
+func WithClock(now func() time.Time) Option {
+	return func(t *Tracker) error {
+		t.now = now
+		return nil
+	}
+}
+
+// Register method
+// Register tracks the authorization created by a Confirm call with the tracker's default policy.
+func (t *Tracker) Register(ctx context.Context, req *linepay.ConfirmRequest, resp *linepay.ConfirmResponse) (*Authorization, error) {
+	if resp.Info.AuthorizationExpireDate == "" {
+		return nil, ErrNoExpireDate
+	}
+	expireAt, err := time.Parse(time.RFC3339, resp.Info.AuthorizationExpireDate)
+	if err != nil {
+		return nil, err
+	}
+	a := &Authorization{
+		TransactionID: resp.Info.TransactionID,
+		OrderID:       resp.Info.OrderID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		ExpireAt:      expireAt,
+		Policy:        t.policy,
+	}
+	if err := t.Track(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Track method
+// Track stores a as a pending authorization.
+func (t *Tracker) Track(ctx context.Context, a *Authorization) error {
+	if a.Policy == "" {
+		a.Policy = t.policy
+	}
+	a.Status = StatusPending
+	a.UpdatedAt = t.now()
+	return t.store.Save(ctx, a)
+}
+
+// Forget method
+// Forget stops tracking an authorization that the application captured or voided by itself.
+func (t *Tracker) Forget(ctx context.Context, transactionID int64) error {
+	return t.store.Delete(ctx, transactionID)
+}
+
+// Run method
+// Run calls RunOnce every interval until ctx is done.
+// Errors of RunOnce are passed to the error func and retried on the next tick.
+func (t *Tracker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		if err := t.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			t.onError(ctx, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce method
+// RunOnce applies the policy of every pending authorization that expires within the lead time.
+// Failed attempts are recorded on the authorization and retried on the next run.
+func (t *Tracker) RunOnce(ctx context.Context) error {
+	due, err := t.store.ListPending(ctx, t.now().Add(t.leadTime))
+	if err != nil {
+		return err
+	}
+	for _, a := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := t.process(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Tracker) process(ctx context.Context, a *Authorization) error {
+	now := t.now()
+	if !now.Before(a.ExpireAt) {
+		a.Status = StatusExpired
+		a.UpdatedAt = now
+		if err := t.store.Save(ctx, a); err != nil {
+			return err
+		}
+		t.notify(ctx, a)
+		return nil
+	}
+
+	var err error
+	switch a.Policy {
+	case PolicyCapture:
+		err = t.capture(ctx, a)
+		if err == nil {
+			a.Status = StatusCaptured
+		}
+	case PolicyVoid:
+		err = t.void(ctx, a)
+		if err == nil {
+			a.Status = StatusVoided
+		}
+	default:
+		a.Status = StatusAlerted
+	}
+	a.Attempts++
+	a.UpdatedAt = now
+	if err != nil {
+		a.LastError = err.Error()
+	} else {
+		a.LastError = ""
+	}
+	if err := t.store.Save(ctx, a); err != nil {
+		return err
+	}
+	if err != nil || a.Status == StatusAlerted {
+		t.notify(ctx, a)
+	}
+	return nil
+}
+
+// capture captures a unless PaymentDetails shows that an earlier attempt already did.
+// A capture that timed out or failed with an ambiguous returnCode is looked up
+// right away, so that it is not sent again blindly on the next run.
+func (t *Tracker) capture(ctx context.Context, a *Authorization) error {
+	if a.Attempts > 0 {
+		captured, err := t.captured(ctx, a)
+		if err != nil || captured {
+			return err
+		}
+	}
+	resp, _, err := t.client.Capture(ctx, a.TransactionID, &linepay.CaptureRequest{
+		Amount:   a.Amount,
+		Currency: a.Currency,
+	})
+	if err == nil {
+		err = linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage)
+		if err == nil || !linepay.AmbiguousReturnCode(resp.ReturnCode) {
+			return err
+		}
+	}
+	if captured, lookupErr := t.captured(ctx, a); lookupErr == nil && captured {
+		return nil
+	}
+	return err
+}
+
+// captured reports whether PaymentDetails shows a as captured.
+func (t *Tracker) captured(ctx context.Context, a *Authorization) (bool, error) {
+	resp, _, err := t.client.PaymentDetails(ctx, &linepay.PaymentDetailsRequest{
+		TransactionID: []int64{a.TransactionID},
+	})
+	if err != nil {
+		return false, err
+	}
+	switch resp.ReturnCode {
+	case linepay.ReturnCodeSuccess:
+	case linepay.ReturnCodeTransactionNotFound:
+		return false, nil
+	default:
+		return false, linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage)
+	}
+	for _, info := range resp.Info {
+		if info.TransactionID == a.TransactionID && info.OriginalTransactionID == 0 {
+			return info.PayStatus == linepay.PayStatusCapture, nil
+		}
+	}
+	return false, nil
+}
+
+func (t *Tracker) void(ctx context.Context, a *Authorization) error {
+	resp, _, err := t.client.Void(ctx, a.TransactionID, &linepay.VoidRequest{})
+	if err != nil {
+		return err
+	}
+	return linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage)
+}
+
+func (t *Tracker) notify(ctx context.Context, a *Authorization) {
+	if t.alert != nil {
+		t.alert(ctx, a)
+	}
+}
+
+func logError(ctx context.Context, err error) {
+	log.Printf("authz: %v", err)
+}
@@ -0,0 +1,258 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func setup(t *testing.T, options ...Option) (*Tracker, *http.ServeMux, *fakeClock, func()) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client, err := linepay.New("testid", "testsecret", linepay.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	options = append([]Option{WithClock(clock.Now)}, options...)
+	tracker, err := New(client, NewMemoryStore(), options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker, mux, clock, server.Close
+}
+
+func confirmResponse(transactionID int64, expire string) *linepay.ConfirmResponse {
+	resp := &linepay.ConfirmResponse{ReturnCode: linepay.ReturnCodeSuccess}
+	resp.Info.TransactionID = transactionID
+	resp.Info.OrderID = "order"
+	resp.Info.AuthorizationExpireDate = expire
+	return resp
+}
+
+func TestTracker_Capture(t *testing.T) {
+	tracker, mux, clock, teardown := setup(t, WithPolicy(PolicyCapture), WithLeadTime(time.Hour))
+	defer teardown()
+
+	captured := 0
+	mux.HandleFunc("/v3/payments/authorizations/1/capture", func(w http.ResponseWriter, r *http.Request) {
+		captured++
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	ctx := context.Background()
+	_, err := tracker.Register(ctx, &linepay.ConfirmRequest{Amount: 100, Currency: "JPY"}, confirmResponse(1, "2021-01-08T00:00:00Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tracker.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if captured != 0 {
+		t.Fatalf("captured %d times before lead time; want 0", captured)
+	}
+
+	clock.now = time.Date(2021, 1, 7, 23, 30, 0, 0, time.UTC)
+	if err := tracker.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if captured != 1 {
+		t.Fatalf("captured %d times; want 1", captured)
+	}
+	a, err := tracker.store.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != StatusCaptured {
+		t.Errorf("Status %s; want %s", a.Status, StatusCaptured)
+	}
+
+	if err := tracker.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if captured != 1 {
+		t.Errorf("captured %d times after completion; want 1", captured)
+	}
+}
+
+func TestTracker_VoidFailureIsRetried(t *testing.T) {
+	var alerts []Authorization
+	tracker, mux, clock, teardown := setup(t, WithAlertFunc(func(ctx context.Context, a *Authorization) {
+		alerts = append(alerts, *a)
+	}))
+	defer teardown()
+
+	returnCode := "9000"
+	mux.HandleFunc("/v3/payments/authorizations/2/void", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"returnCode":"%s","returnMessage":"message"}`, returnCode)
+	})
+
+	ctx := context.Background()
+	clock.now = time.Date(2021, 1, 7, 12, 0, 0, 0, time.UTC)
+	_, err := tracker.Register(ctx, &linepay.ConfirmRequest{Amount: 100, Currency: "JPY"}, confirmResponse(2, "2021-01-08T00:00:00Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tracker.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 || alerts[0].Status != StatusPending || alerts[0].LastError == "" {
+		t.Fatalf("alerts %+v; want one pending alert with error", alerts)
+	}
+
+	returnCode = linepay.ReturnCodeSuccess
+	if err := tracker.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	a, err := tracker.store.Get(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != StatusVoided || a.Attempts != 2 {
+		t.Errorf("Status %s Attempts %d; want %s 2", a.Status, a.Attempts, StatusVoided)
+	}
+}
+
+func TestTracker_AmbiguousCaptureIsLookedUp(t *testing.T) {
+	tracker, mux, clock, teardown := setup(t, WithPolicy(PolicyCapture))
+	defer teardown()
+
+	captured := 0
+	mux.HandleFunc("/v3/payments/authorizations/3/capture", func(w http.ResponseWriter, r *http.Request) {
+		captured++
+		fmt.Fprint(w, `{"returnCode":"9000","returnMessage":"internal error"}`)
+	})
+	payStatus := linepay.PayStatusAuthorization
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"returnCode":"0000","info":[{"transactionId":3,"payStatus":"%s"}]}`, payStatus)
+	})
+
+	ctx := context.Background()
+	clock.now = time.Date(2021, 1, 7, 12, 0, 0, 0, time.UTC)
+	_, err := tracker.Register(ctx, &linepay.ConfirmRequest{Amount: 100, Currency: "JPY"}, confirmResponse(3, "2021-01-08T00:00:00Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	a, err := tracker.store.Get(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != StatusPending || a.LastError == "" {
+		t.Fatalf("Status %s LastError %q; want %s with error", a.Status, a.LastError, StatusPending)
+	}
+
+	// the capture went through after all
+	payStatus = linepay.PayStatusCapture
+	if err := tracker.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if captured != 1 {
+		t.Errorf("captured %d times; want 1", captured)
+	}
+	a, err = tracker.store.Get(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != StatusCaptured || a.LastError != "" {
+		t.Errorf("Status %s LastError %q; want %s", a.Status, a.LastError, StatusCaptured)
+	}
+}
+
+// flakyStore fails the first ListPending call and closes retried on the second.
+type flakyStore struct {
+	*MemoryStore
+	calls   int
+	retried chan struct{}
+}
+
+func (s *flakyStore) ListPending(ctx context.Context, deadline time.Time) ([]*Authorization, error) {
+	s.calls++
+	switch s.calls {
+	case 1:
+		return nil, errors.New("store unavailable")
+	case 2:
+		close(s.retried)
+	}
+	return s.MemoryStore.ListPending(ctx, deadline)
+}
+
+func TestTracker_RunContinuesAfterStoreError(t *testing.T) {
+	client, err := linepay.New("testid", "testsecret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var errs []error
+	store := &flakyStore{MemoryStore: NewMemoryStore(), retried: make(chan struct{})}
+	tracker, err := New(client, store, WithInterval(time.Millisecond), WithErrorFunc(func(ctx context.Context, err error) {
+		errs = append(errs, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- tracker.Run(ctx) }()
+	select {
+	case <-store.retried:
+	case err := <-done:
+		t.Fatalf("Run returned %v before retrying", err)
+	case <-time.After(time.Second):
+		t.Fatal("ListPending not retried")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v; want %v", err, context.Canceled)
+	}
+	if len(errs) != 1 {
+		t.Errorf("errors %v; want one", errs)
+	}
+}
+
+func TestTracker_Expired(t *testing.T) {
+	var alerts []Authorization
+	tracker, _, clock, teardown := setup(t, WithPolicy(PolicyAlert), WithAlertFunc(func(ctx context.Context, a *Authorization) {
+		alerts = append(alerts, *a)
+	}))
+	defer teardown()
+
+	ctx := context.Background()
+	_, err := tracker.Register(ctx, &linepay.ConfirmRequest{Amount: 100, Currency: "JPY"}, confirmResponse(3, "2021-01-08T00:00:00Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.now = time.Date(2021, 1, 9, 0, 0, 0, 0, time.UTC)
+	if err := tracker.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 || alerts[0].Status != StatusExpired {
+		t.Errorf("alerts %+v; want one expired alert", alerts)
+	}
+}
+
+func TestTracker_RegisterWithoutExpireDate(t *testing.T) {
+	tracker, _, _, teardown := setup(t)
+	defer teardown()
+
+	_, err := tracker.Register(context.Background(), &linepay.ConfirmRequest{}, confirmResponse(4, ""))
+	if err != ErrNoExpireDate {
+		t.Errorf("Register returned %v; want %v", err, ErrNoExpireDate)
+	}
+}
@@ -0,0 +1,78 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by Store.Get for an unknown transaction.
+var ErrNotFound = errors.New("authz: authorization not found")
+
+// Store type
+// Store persists tracked authorizations. Implementations must be safe for concurrent use.
+type Store interface {
+	Save(ctx context.Context, a *Authorization) error
+	Get(ctx context.Context, transactionID int64) (*Authorization, error)
+	Delete(ctx context.Context, transactionID int64) error
+	// ListPending returns pending authorizations expiring at or before deadline, earliest first.
+	ListPending(ctx context.Context, deadline time.Time) ([]*Authorization, error)
+}
+
+// MemoryStore type
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[int64]Authorization
+}
+
+// NewMemoryStore returns a new in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[int64]Authorization)}
+}
+
+// Save method
+func (s *MemoryStore) Save(ctx context.Context, a *Authorization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[a.TransactionID] = *a
+	return nil
+}
+
+// Get method
+func (s *MemoryStore) Get(ctx context.Context, transactionID int64) (*Authorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.items[transactionID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &a, nil
+}
+
+// Delete method
+func (s *MemoryStore) Delete(ctx context.Context, transactionID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, transactionID)
+	return nil
+}
+
+// ListPending method
+func (s *MemoryStore) ListPending(ctx context.Context, deadline time.Time) ([]*Authorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*Authorization
+	for _, a := range s.items {
+		if a.Status != StatusPending || a.ExpireAt.After(deadline) {
+			continue
+		}
+		a := a
+		list = append(list, &a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ExpireAt.Before(list[j].ExpireAt)
+	})
+	return list, nil
+}
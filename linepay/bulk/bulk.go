@@ -0,0 +1,413 @@
+// Package bulk refunds or voids many transactions at once with bounded
+// concurrency and rate limiting, checkpointing progress so an interrupted run
+// can be resumed.
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+// Action type
+type Action string
+
+// Action constants
+const (
+	ActionRefund Action = "refund"
+	ActionVoid   Action = "void"
+)
+
+// Row type
+// Row is one input line. Amount is the refund amount; 0 refunds the full amount and is ignored for voids.
+type Row struct {
+	Line          int   `json:"-"`
+	TransactionID int64 `json:"transactionId"`
+	Amount        int   `json:"amount,omitempty"`
+}
+
+func (r *Row) key() string {
+	return fmt.Sprintf("%d:%d", r.Line, r.TransactionID)
+}
+
+// ReadCSV function
+// ReadCSV reads rows from CSV with a header containing transactionId and optionally amount.
+func ReadCSV(r io.Reader) ([]*Row, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	idCol, amountCol := -1, -1
+	for i, h := range header {
+		switch strings.TrimSpace(h) {
+		case "transactionId":
+			idCol = i
+		case "amount":
+			amountCol = i
+		}
+	}
+	if idCol < 0 {
+		return nil, errors.New("bulk: CSV header has no transactionId column")
+	}
+	var rows []*Row
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := &Row{Line: line}
+		if row.TransactionID, err = linepay.ParseInt64(rec[idCol]); err != nil {
+			return nil, fmt.Errorf("bulk: line %d: invalid transactionId %q", line, rec[idCol])
+		}
+		if amountCol >= 0 && rec[amountCol] != "" {
+			if row.Amount, err = strconv.Atoi(rec[amountCol]); err != nil {
+				return nil, fmt.Errorf("bulk: line %d: invalid amount %q", line, rec[amountCol])
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// ReadJSONL function
+// ReadJSONL reads rows from JSON lines such as {"transactionId":2019051300000000000,"amount":100}.
+func ReadJSONL(r io.Reader) ([]*Row, error) {
+	var rows []*Row
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		row := &Row{Line: line}
+		if err := json.Unmarshal(s.Bytes(), row); err != nil {
+			return nil, fmt.Errorf("bulk: line %d: %v", line, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, s.Err()
+}
+
+// Result type
+type Result struct {
+	Line                int    `json:"line"`
+	TransactionID       int64  `json:"transactionId"`
+	Action              Action `json:"action"`
+	Amount              int    `json:"amount,omitempty"`
+	ReturnCode          string `json:"returnCode,omitempty"`
+	ReturnMessage       string `json:"returnMessage,omitempty"`
+	RefundTransactionID int64  `json:"refundTransactionId,omitempty"`
+	Error               string `json:"error,omitempty"`
+	// Unknown is set when the call got no answer or returnCode 9000, so the
+	// action may or may not have been applied.
+	Unknown bool `json:"unknown,omitempty"`
+	// Resolved is set when the outcome of an unknown row was found with PaymentDetails on resume.
+	Resolved bool      `json:"resolved,omitempty"`
+	Resumed  bool      `json:"resumed,omitempty"`
+	SentAt   time.Time `json:"sentAt"`
+	At       time.Time `json:"at"`
+}
+
+// Done method
+// Done reports whether the outcome of the row is known. Unknown rows are
+// checked with PaymentDetails on resume and only sent again if the action is
+// found not to have been applied, or if Options.ResendUnknown is set.
+func (r *Result) Done() bool {
+	return r.ReturnCode != "" && !r.Unknown
+}
+
+// Options type
+type Options struct {
+	Action Action
+	// Concurrency is the number of calls in flight. Defaults to 1.
+	Concurrency int
+	// Interval is the minimum time between two calls. Zero means no limit.
+	Interval time.Duration
+	// Checkpoint is a file that records finished and unknown rows. Finished rows found in it are not sent again.
+	Checkpoint string
+	// ResendUnknown sends unknown rows of the checkpoint again without checking them with PaymentDetails.
+	// A partial refund that went through is then made twice.
+	ResendUnknown bool
+}
+
+// Run function
+// Run applies opts.Action to every row and returns one result per row in input order.
+// Rows already done according to the checkpoint are returned with Resumed set.
+// Rows left unknown by a previous run are looked up with PaymentDetails first,
+// see Result.Done.
+func Run(ctx context.Context, client *linepay.Client, rows []*Row, opts Options) ([]*Result, error) {
+	if opts.Action != ActionRefund && opts.Action != ActionVoid {
+		return nil, fmt.Errorf("bulk: unknown action %q", opts.Action)
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	done := make(map[string]*Result)
+	var checkpoint *os.File
+	if opts.Checkpoint != "" {
+		var err error
+		if done, err = readCheckpoint(opts.Checkpoint); err != nil {
+			return nil, err
+		}
+		checkpoint, err = os.OpenFile(opts.Checkpoint, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		defer checkpoint.Close()
+	}
+
+	var tick <-chan time.Time
+	if opts.Interval > 0 {
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	results := make([]*Result, len(rows))
+	unknown := make(map[int]*Result)
+	for i, row := range rows {
+		if res, ok := done[row.key()]; ok && !res.Done() {
+			unknown[i] = res
+		}
+	}
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var writeErr error
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				var res *Result
+				if prev, ok := unknown[i]; ok && !opts.ResendUnknown {
+					res = resolve(ctx, client, opts.Action, rows[i], prev)
+				}
+				if res == nil {
+					res = apply(ctx, client, opts.Action, rows[i])
+				}
+				results[i] = res
+				if checkpoint == nil || (!res.Done() && !res.Unknown) {
+					continue
+				}
+				mu.Lock()
+				if err := writeCheckpoint(checkpoint, rows[i], res); err != nil && writeErr == nil {
+					writeErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for i, row := range rows {
+		if res, ok := done[row.key()]; ok && res.Done() {
+			res.Resumed = true
+			results[i] = res
+			continue
+		}
+		if tick != nil {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+				break feed
+			case <-tick:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err == nil {
+		err = writeErr
+	}
+	return results, err
+}
+
+func apply(ctx context.Context, client *linepay.Client, action Action, row *Row) *Result {
+	res := &Result{
+		Line:          row.Line,
+		TransactionID: row.TransactionID,
+		Action:        action,
+		Amount:        row.Amount,
+		SentAt:        time.Now(),
+	}
+	var err error
+	switch action {
+	case ActionRefund:
+		var resp *linepay.RefundResponse
+		resp, _, err = client.Refund(ctx, row.TransactionID, &linepay.RefundRequest{RefundAmount: row.Amount})
+		if err == nil {
+			res.ReturnCode = resp.ReturnCode
+			res.ReturnMessage = resp.ReturnMessage
+			res.RefundTransactionID = resp.Info.RefundTransactionID
+		}
+	case ActionVoid:
+		res.Amount = 0
+		var resp *linepay.VoidResponse
+		resp, _, err = client.Void(ctx, row.TransactionID, &linepay.VoidRequest{})
+		if err == nil {
+			res.ReturnCode = resp.ReturnCode
+			res.ReturnMessage = resp.ReturnMessage
+		}
+	}
+	if err != nil {
+		res.Error = err.Error()
+		// Only an open circuit breaker guarantees the call was not sent.
+		res.Unknown = !errors.Is(err, linepay.ErrCircuitOpen)
+	}
+	if res.ReturnCode == linepay.ReturnCodeInternalError {
+		res.Unknown = true
+	}
+	res.At = time.Now()
+	return res
+}
+
+// clockSkew is how much earlier than SentAt LINE Pay may date a refund made by the call.
+const clockSkew = 5 * time.Minute
+
+// resolve looks up whether the action of a row left unknown by prev was applied.
+// It returns nil if it was not, so the row is sent again. A refund counts as
+// applied if a refund of the same amount, or any refund for a full refund, is
+// dated after prev was sent.
+func resolve(ctx context.Context, client *linepay.Client, action Action, row *Row, prev *Result) *Result {
+	res := *prev
+	res.Resumed = true
+	res.At = time.Now()
+	details, _, err := client.PaymentDetails(ctx, &linepay.PaymentDetailsRequest{TransactionID: []int64{row.TransactionID}})
+	if err == nil {
+		err = linepay.CheckReturnCode(details.ReturnCode, details.ReturnMessage)
+	}
+	if err != nil {
+		res.Error = fmt.Sprintf("outcome unknown: %v", err)
+		return &res
+	}
+	for _, info := range details.Info {
+		if info.TransactionID != row.TransactionID {
+			continue
+		}
+		switch action {
+		case ActionRefund:
+			for _, refund := range info.RefundList {
+				if refund.RefundTransactionDate.Before(prev.SentAt.Add(-clockSkew)) {
+					continue
+				}
+				if row.Amount != 0 && linepay.RefundedAmount(refund.RefundAmount) != row.Amount {
+					continue
+				}
+				res.ReturnCode = linepay.ReturnCodeSuccess
+				res.ReturnMessage = ""
+				res.RefundTransactionID = refund.RefundTransactionID
+				res.Error = ""
+				res.Unknown = false
+				res.Resolved = true
+				return &res
+			}
+		case ActionVoid:
+			if info.PayStatus == linepay.PayStatusVoidedAuthorization {
+				res.ReturnCode = linepay.ReturnCodeSuccess
+				res.ReturnMessage = ""
+				res.Error = ""
+				res.Unknown = false
+				res.Resolved = true
+				return &res
+			}
+		}
+	}
+	return nil
+}
+
+type checkpointEntry struct {
+	Key    string  `json:"key"`
+	Result *Result `json:"result"`
+}
+
+func readCheckpoint(path string) (map[string]*Result, error) {
+	done := make(map[string]*Result)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		e := new(checkpointEntry)
+		if err := json.Unmarshal(s.Bytes(), e); err != nil {
+			// a torn last line from an interrupted run; the row is simply redone
+			continue
+		}
+		done[e.Key] = e.Result
+	}
+	return done, s.Err()
+}
+
+func writeCheckpoint(f *os.File, row *Row, res *Result) error {
+	b, err := json.Marshal(&checkpointEntry{Key: row.key(), Result: res})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// WriteReport function
+// WriteReport writes results as CSV.
+func WriteReport(w io.Writer, results []*Result) error {
+	cw := csv.NewWriter(w)
+	header := []string{"line", "transaction_id", "action", "amount", "return_code", "return_message", "refund_transaction_id", "error", "unknown", "resolved", "resumed"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		refundTransactionID := ""
+		if r.RefundTransactionID != 0 {
+			refundTransactionID = strconv.FormatInt(r.RefundTransactionID, 10)
+		}
+		rec := []string{
+			strconv.Itoa(r.Line),
+			strconv.FormatInt(r.TransactionID, 10),
+			string(r.Action),
+			strconv.Itoa(r.Amount),
+			r.ReturnCode,
+			r.ReturnMessage,
+			refundTransactionID,
+			r.Error,
+			strconv.FormatBool(r.Unknown),
+			strconv.FormatBool(r.Resolved),
+			strconv.FormatBool(r.Resumed),
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,207 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+func setup(t *testing.T) (*linepay.Client, *http.ServeMux, func()) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client, err := linepay.New("testid", "testsecret", linepay.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, mux, server.Close
+}
+
+func TestReadCSV(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader("transactionId,amount\n1,100\n2,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Row{{Line: 2, TransactionID: 1, Amount: 100}, {Line: 3, TransactionID: 2}}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows; want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if *rows[i] != want[i] {
+			t.Errorf("row %d = %+v; want %+v", i, *rows[i], want[i])
+		}
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	rows, err := ReadJSONL(strings.NewReader(`{"transactionId":2019051300000000000,"amount":100}` + "\n\n" + `{"transactionId":2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].TransactionID != 2019051300000000000 || rows[1].Line != 3 {
+		t.Errorf("rows %+v %+v", rows[0], rows[1])
+	}
+}
+
+func TestRun_Resume(t *testing.T) {
+	client, mux, teardown := setup(t)
+	defer teardown()
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	failing := true
+	mux.HandleFunc("/v3/payments/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		fail := failing && r.URL.Path == "/v3/payments/3/refund"
+		mu.Unlock()
+		switch {
+		case fail:
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		case r.URL.Path == "/v3/payments/2/refund":
+			fmt.Fprint(w, `{"returnCode":"1165","returnMessage":"already refunded"}`)
+		default:
+			fmt.Fprint(w, `{"returnCode":"0000","info":{"refundTransactionId":9}}`)
+		}
+	})
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		mu.Unlock()
+		// The refund of transaction 3 did not go through.
+		fmt.Fprint(w, `{"returnCode":"0000","info":[{"transactionId":3}]}`)
+	})
+
+	dir, err := ioutil.TempDir("", "bulk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	opts := Options{Action: ActionRefund, Concurrency: 2, Checkpoint: filepath.Join(dir, "checkpoint")}
+	rows, _ := ReadCSV(strings.NewReader("transactionId,amount\n1,100\n2,100\n3,100\n"))
+
+	results, err := Run(context.Background(), client, rows, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].ReturnCode != "0000" || results[1].ReturnCode != "1165" || results[2].Error == "" || !results[2].Unknown {
+		t.Fatalf("results %+v %+v %+v", results[0], results[1], results[2])
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	results, err = Run(context.Background(), client, rows, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := map[string]int{
+		"/v3/payments/1/refund": 1,
+		"/v3/payments/2/refund": 1,
+		"/v3/payments/3/refund": 2,
+		"/v3/payments":          1,
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls %v; want %v", calls, want)
+	}
+	if !results[0].Resumed || !results[1].Resumed || results[2].Resumed || results[2].ReturnCode != "0000" {
+		t.Errorf("results %+v %+v %+v", results[0], results[1], results[2])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 4 || !strings.Contains(lines[2], "1165") {
+		t.Errorf("report:\n%s", buf.String())
+	}
+}
+
+func TestRun_ResumeUnknown(t *testing.T) {
+	client, mux, teardown := setup(t)
+	defer teardown()
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	refunded := ""
+	mux.HandleFunc("/v3/payments/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[r.URL.Path]++
+		switch r.URL.Path {
+		case "/v3/payments/1/refund":
+			// The refund goes through but LINE Pay answers with an internal error.
+			refunded = time.Now().UTC().Format(time.RFC3339)
+			fmt.Fprint(w, `{"returnCode":"9000","returnMessage":"internal error"}`)
+		case "/v3/payments/2/refund":
+			ioutil.ReadAll(r.Body)
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}
+	})
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[r.URL.Path+"?"+r.URL.RawQuery]++
+		switch r.URL.Query().Get("transactionId") {
+		case "1":
+			fmt.Fprintf(w, `{"returnCode":"0000","info":[{"transactionId":1,"refundList":[
+				{"refundTransactionId":8,"refundAmount":-50,"refundTransactionDate":"2000-01-01T00:00:00Z"},
+				{"refundTransactionId":9,"refundAmount":-100,"refundTransactionDate":%q}]}]}`, refunded)
+		default:
+			fmt.Fprint(w, `{"returnCode":"0000","info":[{"transactionId":2}]}`)
+		}
+	})
+
+	dir, err := ioutil.TempDir("", "bulk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	opts := Options{Action: ActionRefund, Checkpoint: filepath.Join(dir, "checkpoint")}
+	rows, _ := ReadCSV(strings.NewReader("transactionId,amount\n1,100\n2,100\n"))
+
+	results, err := Run(context.Background(), client, rows, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Unknown || results[0].Done() || !results[1].Unknown || results[1].Done() {
+		t.Fatalf("results %+v %+v; want both unknown", results[0], results[1])
+	}
+
+	if results, err = Run(context.Background(), client, rows, opts); err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; !r.Resolved || !r.Done() || r.RefundTransactionID != 9 {
+		t.Errorf("result of transaction 1 %+v; want resolved refund 9", r)
+	}
+	mu.Lock()
+	if calls["/v3/payments/1/refund"] != 1 || calls["/v3/payments/2/refund"] != 2 {
+		t.Errorf("calls %v; want transaction 1 refunded once and 2 twice", calls)
+	}
+	mu.Unlock()
+
+	// Resolved rows are done for good.
+	if _, err = Run(context.Background(), client, rows, opts); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["/v3/payments?transactionId=1"] != 1 {
+		t.Errorf("calls %v; want transaction 1 looked up once", calls)
+	}
+}
@@ -0,0 +1,190 @@
+package linepay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// CallOption type
+// CallOption changes a single API call.
+type CallOption func(*callConfig)
+
+type callConfig struct {
+	header   http.Header
+	timeout  time.Duration
+	raw      *[]byte
+	noRetry  bool
+	endpoint string
+}
+
+// WithHeader function
+// WithHeader adds a header to the request. The signature headers cannot be overridden.
+func WithHeader(key, value string) CallOption {
+	return func(cfg *callConfig) {
+		if cfg.header == nil {
+			cfg.header = http.Header{}
+		}
+		cfg.header.Add(key, value)
+	}
+}
+
+// WithCallTimeout function
+// WithCallTimeout replaces the operation timeout for this call, see WithTimeouts.
+func WithCallTimeout(d time.Duration) CallOption {
+	return func(cfg *callConfig) {
+		cfg.timeout = d
+	}
+}
+
+// WithRawResponseCapture function
+// WithRawResponseCapture stores the exact bytes of the response body in *dst.
+func WithRawResponseCapture(dst *[]byte) CallOption {
+	return func(cfg *callConfig) {
+		cfg.raw = dst
+	}
+}
+
+// WithoutRetry function
+// WithoutRetry sends the request once, without the retries of WithRetry and WithCredentialsGrace.
+func WithoutRetry() CallOption {
+	return func(cfg *callConfig) {
+		cfg.noRetry = true
+	}
+}
+
+// WithCallEndpoint function
+// WithCallEndpoint sends this call to endpoint instead of the endpoint of the client.
+func WithCallEndpoint(endpoint string) CallOption {
+	return func(cfg *callConfig) {
+		cfg.endpoint = endpoint
+	}
+}
+
+type callConfigKey struct{}
+
+func callConfigFrom(ctx context.Context) *callConfig {
+	if cfg, ok := ctx.Value(callConfigKey{}).(*callConfig); ok {
+		return cfg
+	}
+	return &callConfig{}
+}
+
+// call builds the request of an API method, applies options and sends it with Do.
+func (c *Client) call(ctx context.Context, op Operation, method, path string, body, v interface{}, options []CallOption) (*http.Response, error) {
+	cfg := &callConfig{}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	endpoint := c.endpoint
+	if cfg.endpoint != "" {
+		u, err := url.Parse(cfg.endpoint)
+		if err != nil {
+			return nil, err
+		}
+		endpoint = u
+	}
+	httpReq, err := c.newRequest(endpoint, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range cfg.header {
+		if _, ok := httpReq.Header[key]; ok {
+			continue
+		}
+		httpReq.Header[key] = values
+	}
+
+	ctx = withOperation(ctx, op)
+	ctx = context.WithValue(ctx, callConfigKey{}, cfg)
+	return c.Do(ctx, httpReq, v)
+}
+
+// Call method
+// Call sends req to path the same way the API methods do, for endpoints the SDK
+// does not wrap yet. For GET and DELETE req is encoded as query parameters,
+// otherwise as the JSON body. The response body is decoded into resp.
+// Unlike the API methods, Call returns *Error when the returnCode is not
+// ReturnCodeSuccess; resp is decoded regardless.
+// Its timeout is looked up as OperationCall, which defaults to 60 seconds; see WithTimeouts.
+func (c *Client) Call(ctx context.Context, method, path string, req, resp interface{}, options ...CallOption) (*http.Response, error) {
+	raw := &RawResponse{Value: resp}
+	httpResp, err := c.call(ctx, OperationCall, method, path, req, raw, options)
+	if err != nil {
+		return httpResp, err
+	}
+	var result struct {
+		ReturnCode    string `json:"returnCode"`
+		ReturnMessage string `json:"returnMessage"`
+	}
+	if json.Unmarshal(raw.Body, &result) != nil || result.ReturnCode == "" {
+		return httpResp, nil
+	}
+	return httpResp, CheckReturnCode(result.ReturnCode, result.ReturnMessage)
+}
+
+// Retry type
+// Retry resends inquiry calls, which are safe to repeat, after a transport error
+// or a 5xx response. Payment calls are never resent; see ConfirmSafely instead.
+type Retry struct {
+	// MaxAttempts is the number of times a call is sent at most. The default is 3.
+	MaxAttempts int
+	// Backoff is the wait before the first resend, doubled for each further one. The default is 100ms.
+	Backoff time.Duration
+}
+
+// WithRetry function
+func WithRetry(r Retry) ClientOption {
+	return func(client *Client) error {
+		if r.MaxAttempts < 0 || r.Backoff < 0 {
+			return errors.New("negative retry setting")
+		}
+		if r.MaxAttempts == 0 {
+			r.MaxAttempts = 3
+		}
+		if r.Backoff == 0 {
+			r.Backoff = 100 * time.Millisecond
+		}
+		client.retry = &r
+		return nil
+	}
+}
+
+// sendRetrying sends req and resends it as allowed by the credentials grace and the retry settings.
+func (c *Client) sendRetrying(ctx context.Context, req *http.Request, v interface{}, noRetry bool) (*http.Response, []byte, error) {
+	resp, body, err := c.send(ctx, req, v)
+	if noRetry {
+		return resp, body, err
+	}
+	if err == nil {
+		if retry := c.resign(req, body); retry != nil {
+			return c.send(ctx, retry, v)
+		}
+	}
+	if c.retry == nil || classOf(req) != ClassInquiry {
+		return resp, body, err
+	}
+	_, writer := v.(io.Writer)
+	backoff := c.retry.Backoff
+	for attempt := 1; attempt < c.retry.MaxAttempts; attempt++ {
+		failed := (err != nil && resp == nil) || (err == nil && resp.StatusCode >= 500 && !writer)
+		if !failed || ctx.Err() != nil {
+			break
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, nil, ctx.Err()
+		case <-t.C:
+		}
+		backoff *= 2
+		resp, body, err = c.send(ctx, req, v)
+	}
+	return resp, body, err
+}
@@ -0,0 +1,163 @@
+package linepay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCallOptions(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	const body = `{"returnCode":"0000","info":{"transactionId":1}}`
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Trace-Id"); got != "t1" {
+			t.Errorf("X-Trace-Id %q; want t1", got)
+		}
+		if got := r.Header.Get("X-LINE-ChannelId"); got != "testid" {
+			t.Errorf("X-LINE-ChannelId %q; want testid", got)
+		}
+		fmt.Fprint(w, body)
+	})
+	mux.HandleFunc("/v3/payments/2/confirm", func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		<-r.Context().Done()
+	})
+
+	ctx := context.Background()
+	var raw []byte
+	resp, _, err := client.Confirm(ctx, 1, &ConfirmRequest{},
+		WithHeader("X-Trace-Id", "t1"),
+		WithHeader("X-LINE-ChannelId", "other"),
+		WithRawResponseCapture(&raw),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != body || resp.Info.TransactionID != 1 {
+		t.Errorf("raw %q, response %+v", raw, resp)
+	}
+
+	_, _, err = client.Confirm(ctx, 2, &ConfirmRequest{}, WithCallTimeout(10*time.Millisecond))
+	var terr *TimeoutError
+	if !errors.As(err, &terr) || terr.Limit != 10*time.Millisecond || terr.Operation != OperationConfirm {
+		t.Errorf("Confirm returned %v; want a 10ms TimeoutError", err)
+	}
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"1150"}`)
+	}))
+	defer other.Close()
+	resp, _, err = client.Confirm(ctx, 1, &ConfirmRequest{}, WithCallEndpoint(other.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReturnCode != "1150" {
+		t.Errorf("returnCode %s from the alternate endpoint; want 1150", resp.ReturnCode)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	_, mux, serverURL, teardown := setup()
+	defer teardown()
+
+	var details, confirms int
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		details++
+		if details%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"returnCode":"9000"}`)
+			return
+		}
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		confirms++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"returnCode":"9000"}`)
+	})
+
+	client, err := New("testid", "testsecret", WithEndpoint(serverURL), WithRetry(Retry{Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	req := &PaymentDetailsRequest{TransactionID: []int64{1}}
+
+	resp, _, err := client.PaymentDetails(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReturnCode != "0000" || details != 3 {
+		t.Errorf("returnCode %s after %d calls; want 0000 after 3", resp.ReturnCode, details)
+	}
+
+	resp, _, err = client.PaymentDetails(ctx, req, WithoutRetry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReturnCode != "9000" || details != 4 {
+		t.Errorf("returnCode %s after %d calls without retry; want 9000 after 4", resp.ReturnCode, details)
+	}
+
+	if _, _, err := client.Confirm(ctx, 1, &ConfirmRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if confirms != 1 {
+		t.Errorf("confirm sent %d times; want 1", confirms)
+	}
+}
+
+func TestClient_Call(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/new", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "orderId=o1" {
+			t.Errorf("query %q; want orderId=o1", r.URL.RawQuery)
+		}
+		if r.Header.Get("X-LINE-Authorization") == "" {
+			t.Error("request is not signed")
+		}
+		fmt.Fprint(w, `{"returnCode":"0000","info":{"value":1}}`)
+	})
+	mux.HandleFunc("/v3/payments/new/1", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if string(b) != `{"amount":100}` {
+			t.Errorf("body %s", b)
+		}
+		fmt.Fprint(w, `{"returnCode":"1150","returnMessage":"not found"}`)
+	})
+
+	type info struct {
+		Info struct {
+			Value int `json:"value"`
+		} `json:"info"`
+	}
+	ctx := context.Background()
+	var resp info
+	query := &struct {
+		OrderID string `url:"orderId"`
+	}{"o1"}
+	if _, err := client.Call(ctx, http.MethodGet, "/v3/payments/new", query, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Info.Value != 1 {
+		t.Errorf("value %d; want 1", resp.Info.Value)
+	}
+
+	body := &struct {
+		Amount int `json:"amount"`
+	}{100}
+	_, err := client.Call(ctx, http.MethodPost, "/v3/payments/new/1", body, &resp)
+	var lerr *Error
+	if !errors.As(err, &lerr) || lerr.ReturnCode != "1150" {
+		t.Errorf("Call returned %v; want returnCode 1150", err)
+	}
+}
@@ -0,0 +1,225 @@
+package linepay
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling LINE Pay while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("linepay: circuit breaker is open")
+
+// CircuitState type
+type CircuitState int
+
+// CircuitState constants
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+// String method
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker type
+// CircuitBreaker configures WithCircuitBreaker. Zero fields take the documented defaults.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before probing LINE Pay again. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls is the number of probe calls let through while half-open. Defaults to 1.
+	HalfOpenMaxCalls int
+	// FailureReturnCodes are returnCodes counted as failures in addition to
+	// transport errors, operation timeouts (see WithTimeouts) and HTTP 5xx.
+	// Defaults to 9000 (internal error).
+	FailureReturnCodes []string
+	// OnStateChange is called after every state change.
+	OnStateChange func(from, to CircuitState)
+}
+
+// WithCircuitBreaker function
+// WithCircuitBreaker makes the client fail fast with ErrCircuitOpen while LINE Pay is failing.
+func WithCircuitBreaker(cb CircuitBreaker) ClientOption {
+	return func(client *Client) error {
+		if cb.FailureThreshold < 0 || cb.OpenTimeout < 0 || cb.HalfOpenMaxCalls < 0 {
+			return errors.New("circuit breaker settings must not be negative")
+		}
+		if cb.FailureThreshold == 0 {
+			cb.FailureThreshold = 5
+		}
+		if cb.OpenTimeout == 0 {
+			cb.OpenTimeout = 30 * time.Second
+		}
+		if cb.HalfOpenMaxCalls == 0 {
+			cb.HalfOpenMaxCalls = 1
+		}
+		if cb.FailureReturnCodes == nil {
+			cb.FailureReturnCodes = []string{ReturnCodeInternalError}
+		}
+		b := &circuitBreaker{
+			settings:    cb,
+			failureCode: make(map[string]bool),
+			now:         time.Now,
+		}
+		for _, code := range cb.FailureReturnCodes {
+			b.failureCode[code] = true
+		}
+		client.breaker = b
+		return nil
+	}
+}
+
+// CircuitState method
+// CircuitState returns the state of the circuit breaker, or CircuitClosed if none is configured.
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.currentState()
+}
+
+// circuitBreaker type
+type circuitBreaker struct {
+	settings    CircuitBreaker
+	failureCode map[string]bool
+	now         func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// outcome of a call as seen by the breaker
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	// outcomeIgnored is used when the caller gave up, which says nothing about LINE Pay.
+	outcomeIgnored
+)
+
+func (b *circuitBreaker) currentState() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.settings.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a call may proceed and whether it is a half-open probe.
+// Every allowed call must be followed by done with the same probe value.
+func (b *circuitBreaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	var from CircuitState
+	changed := false
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.settings.OpenTimeout {
+		from, changed = b.setState(CircuitHalfOpen)
+	}
+	switch b.state {
+	case CircuitOpen:
+		err = ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.probes >= b.settings.HalfOpenMaxCalls {
+			err = ErrCircuitOpen
+		} else {
+			b.probes++
+			probe = true
+		}
+	}
+	b.mu.Unlock()
+	if changed {
+		b.notify(from, CircuitHalfOpen)
+	}
+	return probe, err
+}
+
+// done records the outcome of a call. Only a probe finishing while the circuit
+// is still half-open decides it; a call let through before the circuit opened
+// does not close it, and a probe outliving its half-open period changes nothing.
+func (b *circuitBreaker) done(o outcome, probe bool) {
+	b.mu.Lock()
+	var from, to CircuitState
+	changed := false
+	wasProbe := probe && b.state == CircuitHalfOpen
+	if wasProbe {
+		b.probes--
+	}
+	switch o {
+	case outcomeSuccess:
+		b.failures = 0
+		if wasProbe {
+			to = CircuitClosed
+			from, changed = b.setState(to)
+		}
+	case outcomeFailure:
+		b.failures++
+		if wasProbe || (b.state == CircuitClosed && b.failures >= b.settings.FailureThreshold) {
+			to = CircuitOpen
+			from, changed = b.setState(to)
+		}
+	}
+	b.mu.Unlock()
+	if changed {
+		b.notify(from, to)
+	}
+}
+
+// setState must be called with mu held.
+func (b *circuitBreaker) setState(to CircuitState) (from CircuitState, changed bool) {
+	from = b.state
+	if from == to {
+		return from, false
+	}
+	b.state = to
+	switch to {
+	case CircuitOpen:
+		b.openedAt = b.now()
+		b.probes = 0
+	case CircuitClosed:
+		b.failures = 0
+		b.probes = 0
+	}
+	return from, true
+}
+
+func (b *circuitBreaker) notify(from, to CircuitState) {
+	if b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(from, to)
+	}
+}
+
+// classify turns the result of an HTTP round trip into an outcome.
+// ctxErr is the error of the caller's context after the call. The deadline of
+// the operation firing while the caller's context is fine counts as a failure.
+func (b *circuitBreaker) classify(resp *http.Response, body []byte, err error, ctxErr error) outcome {
+	if err != nil {
+		if ctxErr != nil || err == ErrRateLimitWait {
+			return outcomeIgnored
+		}
+		return outcomeFailure
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return outcomeFailure
+	}
+	if len(body) > 0 && b.failureCode[peekReturnCode(body)] {
+		return outcomeFailure
+	}
+	return outcomeSuccess
+}
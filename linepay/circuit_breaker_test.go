@@ -0,0 +1,179 @@
+package linepay
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	_, mux, serverURL, teardown := setup()
+	defer teardown()
+
+	var changes []string
+	client, err := New("testid", "testsecret", WithEndpoint(serverURL), WithCircuitBreaker(CircuitBreaker{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		OnStateChange: func(from, to CircuitState) {
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	client.breaker.now = func() time.Time { return now }
+
+	calls := 0
+	returnCode := ReturnCodeInternalError
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"returnCode":"%s"}`, returnCode)
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, _, err := client.Confirm(ctx, 1, &ConfirmRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.CircuitState() != CircuitOpen {
+		t.Fatalf("state %s; want open", client.CircuitState())
+	}
+	if _, _, err := client.Confirm(ctx, 1, &ConfirmRequest{}); err != ErrCircuitOpen {
+		t.Fatalf("Confirm returned %v; want %v", err, ErrCircuitOpen)
+	}
+	if calls != 2 {
+		t.Errorf("confirm called %d times; want 2", calls)
+	}
+
+	now = now.Add(time.Minute)
+	returnCode = ReturnCodeSuccess
+	if _, _, err := client.Confirm(ctx, 1, &ConfirmRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if client.CircuitState() != CircuitClosed {
+		t.Errorf("state %s; want closed", client.CircuitState())
+	}
+	want := "[closed->open open->half-open half-open->closed]"
+	if got := fmt.Sprint(changes); got != want {
+		t.Errorf("state changes %s; want %s", got, want)
+	}
+}
+
+func TestClient_CircuitBreakerIgnoresCallerCancellation(t *testing.T) {
+	_, mux, serverURL, teardown := setup()
+	defer teardown()
+
+	client, err := New("testid", "testsecret", WithEndpoint(serverURL), WithCircuitBreaker(CircuitBreaker{FailureThreshold: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := client.Confirm(ctx, 1, &ConfirmRequest{}); err != context.Canceled {
+		t.Fatalf("Confirm returned %v; want %v", err, context.Canceled)
+	}
+	if client.CircuitState() != CircuitClosed {
+		t.Errorf("state %s; want closed", client.CircuitState())
+	}
+}
+
+func TestClient_CircuitBreakerOperationTimeout(t *testing.T) {
+	_, mux, serverURL, teardown := setup()
+	defer teardown()
+
+	client, err := New("testid", "testsecret", WithEndpoint(serverURL),
+		WithTimeouts(Timeouts{OperationConfirm: 20 * time.Millisecond}),
+		WithCircuitBreaker(CircuitBreaker{FailureThreshold: 2, OpenTimeout: time.Minute}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		<-r.Context().Done()
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, _, err := client.Confirm(ctx, 1, &ConfirmRequest{})
+		if te, ok := err.(*TimeoutError); !ok || te.Caller {
+			t.Fatalf("Confirm returned %v; want an operation *TimeoutError", err)
+		}
+	}
+	if client.CircuitState() != CircuitOpen {
+		t.Errorf("state %s after operation timeouts; want open", client.CircuitState())
+	}
+}
+
+func TestClient_CircuitBreakerIgnoresRateLimitWait(t *testing.T) {
+	_, mux, serverURL, teardown := setup()
+	defer teardown()
+
+	client, err := New("testid", "testsecret", WithEndpoint(serverURL),
+		WithRateLimit(RateLimit{Payment: Limit{Rate: 0.1, Burst: 1}}),
+		WithTimeouts(Timeouts{OperationConfirm: 20 * time.Millisecond}),
+		WithCircuitBreaker(CircuitBreaker{FailureThreshold: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	ctx := context.Background()
+	if _, _, err := client.Confirm(ctx, 1, &ConfirmRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := client.Confirm(ctx, 1, &ConfirmRequest{}); err != ErrRateLimitWait {
+		t.Fatalf("Confirm returned %v; want %v", err, ErrRateLimitWait)
+	}
+	if client.CircuitState() != CircuitClosed {
+		t.Errorf("state %s after rate limit wait; want closed", client.CircuitState())
+	}
+}
+
+func TestCircuitBreaker_LateCallIsNotProbe(t *testing.T) {
+	client, err := New("testid", "testsecret", WithCircuitBreaker(CircuitBreaker{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := client.breaker
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	// slow is let through while the circuit is closed and finishes after it went half-open.
+	slow, err := b.allow()
+	if err != nil || slow {
+		t.Fatalf("allow returned %v, %v; want a regular call", slow, err)
+	}
+	failing, _ := b.allow()
+	b.done(outcomeFailure, failing)
+	now = now.Add(time.Minute)
+	probe, err := b.allow()
+	if err != nil || !probe {
+		t.Fatalf("allow returned %v, %v; want a probe", probe, err)
+	}
+
+	b.done(outcomeSuccess, slow)
+	if state := client.CircuitState(); state != CircuitHalfOpen {
+		t.Errorf("state %s after the late call; want half-open", state)
+	}
+	if _, err := b.allow(); err != ErrCircuitOpen {
+		t.Errorf("allow returned %v while the probe is running; want %v", err, ErrCircuitOpen)
+	}
+	b.done(outcomeSuccess, probe)
+	if state := client.CircuitState(); state != CircuitClosed {
+		t.Errorf("state %s after the probe; want closed", state)
+	}
+}
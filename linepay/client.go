@@ -9,9 +9,11 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"reflect"
+	"strings"
 
 	"github.com/google/go-querystring/query"
 	"github.com/google/uuid"
@@ -27,8 +29,17 @@ const (
 type Client struct {
 	channelID     string
 	channelSecret string
+	credentials   CredentialsProvider
+	grace         *credentialsGrace
 	endpoint      *url.URL
 	httpClient    *http.Client
+	limiter       *rateLimiter
+	breaker       *circuitBreaker
+	timeouts      Timeouts
+	retry         *Retry
+	nonce         func() string
+
+	maxResponseSize int64
 }
 
 // ClientOption type
@@ -36,16 +47,14 @@ type ClientOption func(*Client) error
 
 // New returns a new pay client instance.
 func New(channelID, channelSecret string, options ...ClientOption) (*Client, error) {
-	if channelID == "" {
-		return nil, errors.New("missing channel id")
-	}
-	if channelSecret == "" {
-		return nil, errors.New("missing channel secret")
-	}
 	c := &Client{
 		channelID:     channelID,
 		channelSecret: channelSecret,
 		httpClient:    http.DefaultClient,
+		timeouts:      DefaultTimeouts,
+		nonce:         func() string { return uuid.New().String() },
+
+		maxResponseSize: DefaultMaxResponseSize,
 	}
 	for _, option := range options {
 		err := option(c)
@@ -53,6 +62,15 @@ func New(channelID, channelSecret string, options ...ClientOption) (*Client, err
 			return nil, err
 		}
 	}
+	if c.credentials == nil {
+		if channelID == "" {
+			return nil, errors.New("missing channel id")
+		}
+		if channelSecret == "" {
+			return nil, errors.New("missing channel secret")
+		}
+		c.credentials = NewStaticCredentialsProvider(channelID, channelSecret)
+	}
 	if c.endpoint == nil {
 		u, err := url.Parse(APIEndpointReal)
 		if err != nil {
@@ -89,6 +107,9 @@ func WithSandbox() ClientOption {
 }
 
 // mergeQuery method
+// mergeQuery adds the fields of q to the query of path. A slice field is
+// encoded as the key repeated once per element in slice order, keys are sorted,
+// and the result is what newRequest signs, so the same q always gets the same signature.
 func (c *Client) mergeQuery(path string, q interface{}) (string, error) {
 	v := reflect.ValueOf(q)
 	if v.Kind() == reflect.Ptr && v.IsNil() {
@@ -104,6 +125,9 @@ func (c *Client) mergeQuery(path string, q interface{}) (string, error) {
 	if err != nil {
 		return path, err
 	}
+	for k, vs := range u.Query() {
+		qs[k] = append(vs, qs[k]...)
+	}
 
 	u.RawQuery = qs.Encode()
 	return u.String(), nil
@@ -111,7 +135,23 @@ func (c *Client) mergeQuery(path string, q interface{}) (string, error) {
 
 // NewRequest method
 func (c *Client) NewRequest(method, path string, body interface{}) (*http.Request, error) {
-	message := c.channelSecret + path
+	return c.newRequest(c.endpoint, method, path, body)
+}
+
+// newRequest builds a signed request of path relative to endpoint.
+func (c *Client) newRequest(endpoint *url.URL, method, path string, body interface{}) (*http.Request, error) {
+	creds, err := c.credentials.Credentials()
+	if err != nil {
+		return nil, err
+	}
+	if c.grace != nil {
+		c.grace.observe(creds)
+	}
+
+	message := path
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		message = path[:i]
+	}
 
 	switch method {
 	case http.MethodGet, http.MethodDelete:
@@ -123,7 +163,7 @@ func (c *Client) NewRequest(method, path string, body interface{}) (*http.Reques
 			path = merged
 		}
 	}
-	u, err := c.endpoint.Parse(path)
+	u, err := endpoint.Parse(path)
 	if err != nil {
 		return nil, err
 	}
@@ -131,9 +171,7 @@ func (c *Client) NewRequest(method, path string, body interface{}) (*http.Reques
 	var reqBody io.ReadWriter
 	switch method {
 	case http.MethodGet, http.MethodDelete:
-		if body != nil {
-			message += u.RawQuery
-		}
+		message += u.RawQuery
 	case http.MethodPost, http.MethodPut:
 		if body != nil {
 			b, err := json.Marshal(body)
@@ -145,7 +183,7 @@ func (c *Client) NewRequest(method, path string, body interface{}) (*http.Reques
 		}
 	}
 
-	nounce := uuid.New().String()
+	nounce := c.nonce()
 	message += nounce
 
 	req, err := http.NewRequest(method, u.String(), reqBody)
@@ -153,37 +191,141 @@ func (c *Client) NewRequest(method, path string, body interface{}) (*http.Reques
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-LINE-ChannelId", c.channelID)
 	req.Header.Set("X-LINE-Authorization-Nonce", nounce)
+	sign(req, creds, message)
+	if c.grace != nil {
+		req = req.WithContext(context.WithValue(req.Context(), signingKey{}, &signing{creds: creds, message: message}))
+	}
+	return req, nil
+}
+
+type signingKey struct{}
 
-	hash := hmac.New(sha256.New, []byte(c.channelSecret))
-	hash.Write([]byte(message))
+// signing is what NewRequest signed, kept to sign the request again with other credentials.
+type signing struct {
+	creds   *Credentials
+	message string
+}
+
+// sign sets the channel id and the signature of channelSecret + message.
+func sign(req *http.Request, creds *Credentials, message string) {
+	req.Header.Set("X-LINE-ChannelId", creds.ChannelID)
+	hash := hmac.New(sha256.New, []byte(creds.ChannelSecret))
+	hash.Write([]byte(creds.ChannelSecret + message))
 	req.Header.Set("X-LINE-Authorization", base64.StdEncoding.EncodeToString(hash.Sum(nil)))
-	return req, nil
+}
+
+// resign returns a copy of req signed with the previous credentials if the
+// response body calls for the credentials grace, or nil.
+func (c *Client) resign(req *http.Request, body []byte) *http.Request {
+	if c.grace == nil || body == nil {
+		return nil
+	}
+	s, ok := req.Context().Value(signingKey{}).(*signing)
+	if !ok {
+		return nil
+	}
+	previous := c.grace.fallback(s.creds, body)
+	if previous == nil {
+		return nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		b, err := req.GetBody()
+		if err != nil {
+			return nil
+		}
+		retry.Body = b
+	}
+	sign(retry, previous, s.message)
+	return retry
 }
 
 // Do method
+// The response body is copied to v if it is an io.Writer, kept in v.Body if it is
+// a *RawResponse and decoded as JSON into v otherwise.
+// Calls made by the API methods are bounded by the timeout of their operation, see WithTimeouts.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
+	cfg := callConfigFrom(ctx)
+	parent := ctx
+	ctx, cancel, timeout, applied := c.callTimeout(ctx)
+	defer cancel()
+
+	var probe bool
+	if c.breaker != nil {
+		var err error
+		if probe, err = c.breaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+	resp, body, err := c.sendRetrying(ctx, req, v, cfg.noRetry)
+	if c.breaker != nil {
+		c.breaker.done(c.breaker.classify(resp, body, err, parent.Err()), probe)
+	}
+	if err != nil {
+		return resp, timeoutError(parent, operationFrom(parent), timeout, applied, err)
+	}
+	if cfg.raw != nil {
+		*cfg.raw = body
+	}
+	if raw, ok := v.(*RawResponse); ok {
+		raw.Body = body
+		v = raw.Value
+	}
+	if body != nil && v != nil {
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(v); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+// send waits for the rate limiter and performs the round trip.
+// The body is copied to v if it is an io.Writer and returned otherwise,
+// up to the maximum response size.
+func (c *Client) send(ctx context.Context, req *http.Request, v interface{}) (*http.Response, []byte, error) {
+	class := classOf(req)
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx, class); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	resp, err := c.httpClient.Do(req.WithContext(ctx))
 	if err != nil {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return nil, nil, ctx.Err()
 		default:
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
 	defer resp.Body.Close()
 
+	// read one byte more than allowed to tell a body of exactly the limit from a larger one
+	r := io.LimitReader(resp.Body, c.maxResponseSize+1)
+	var body []byte
 	if v != nil {
+		var n int64
 		if w, ok := v.(io.Writer); ok {
-			io.Copy(w, resp.Body)
+			n, err = io.Copy(w, r)
 		} else {
-			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
-				return resp, err
+			body, err = ioutil.ReadAll(r)
+			n = int64(len(body))
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return resp, nil, ctx.Err()
 			}
+			return resp, nil, err
+		}
+		if n > c.maxResponseSize {
+			return resp, nil, ErrResponseTooLarge
 		}
 	}
-	return resp, err
+	if c.limiter != nil {
+		c.limiter.observe(class, resp, body)
+	}
+	return resp, body, nil
 }
@@ -137,3 +137,72 @@ func TestClient_Do(t *testing.T) {
 		t.Errorf("Response body = %v, want %v", body, want)
 	}
 }
+
+func TestClient_NewRequestSignature(t *testing.T) {
+	client, _, _, teardown := setup()
+	defer teardown()
+
+	details := &PaymentDetailsRequest{
+		TransactionID: []int64{2, 1},
+		OrderID:       []string{"a b", "c"},
+		Fields:        "ORDER",
+	}
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		body      interface{}
+		nonce     string
+		wantQuery string
+		wantSig   string
+	}{
+		{
+			name:      "repeated query keys",
+			method:    http.MethodGet,
+			path:      "/v3/payments",
+			body:      details,
+			nonce:     "nonce-1",
+			wantQuery: "fields=ORDER&orderId=a+b&orderId=c&transactionId=2&transactionId=1",
+			wantSig:   "ETiYMuKAMPwPZbEAKxF+Zj7TYgO97AZc0zlbC1+Fmgo=",
+		},
+		{
+			name:   "query in path",
+			method: http.MethodGet,
+			path:   "/v3/payments?transactionId=2",
+			body: &PaymentDetailsRequest{
+				TransactionID: []int64{1},
+				OrderID:       []string{"a b", "c"},
+				Fields:        "ORDER",
+			},
+			nonce:     "nonce-1",
+			wantQuery: "fields=ORDER&orderId=a+b&orderId=c&transactionId=2&transactionId=1",
+			wantSig:   "ETiYMuKAMPwPZbEAKxF+Zj7TYgO97AZc0zlbC1+Fmgo=",
+		},
+		{
+			name:    "json body",
+			method:  http.MethodPost,
+			path:    "/v3/payments/request",
+			body:    map[string]int{"amount": 100},
+			nonce:   "nonce-2",
+			wantSig: "rLrpoBRfyipl5jgMVl5uRiwhMQY2OhbmuD2QgGqQiWs=",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.nonce = func() string { return tt.nonce }
+			req, err := client.NewRequest(tt.method, tt.path, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.URL.RawQuery != tt.wantQuery {
+				t.Errorf("query %q; want %q", req.URL.RawQuery, tt.wantQuery)
+			}
+			if got := req.Header.Get("X-LINE-Authorization-Nonce"); got != tt.nonce {
+				t.Errorf("nonce %q; want %q", got, tt.nonce)
+			}
+			if got := req.Header.Get("X-LINE-Authorization"); got != tt.wantSig {
+				t.Errorf("signature %q; want %q", got, tt.wantSig)
+			}
+		})
+	}
+}
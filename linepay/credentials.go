@@ -0,0 +1,229 @@
+package linepay
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Credentials type
+type Credentials struct {
+	ChannelID     string `json:"channelId"`
+	ChannelSecret string `json:"channelSecret"`
+}
+
+// CredentialsProvider type
+// CredentialsProvider is consulted by NewRequest for every request, so that
+// the channel secret can be rotated without recreating the Client.
+type CredentialsProvider interface {
+	Credentials() (*Credentials, error)
+}
+
+type staticCredentialsProvider struct {
+	creds Credentials
+}
+
+// NewStaticCredentialsProvider returns a CredentialsProvider that always returns the given credentials.
+func NewStaticCredentialsProvider(channelID, channelSecret string) CredentialsProvider {
+	return &staticCredentialsProvider{creds: Credentials{ChannelID: channelID, ChannelSecret: channelSecret}}
+}
+
+// Credentials method
+func (p *staticCredentialsProvider) Credentials() (*Credentials, error) {
+	creds := p.creds
+	return &creds, nil
+}
+
+// Environment variables read by NewEnvCredentialsProvider by default.
+const (
+	EnvChannelID     = "LINE_PAY_CHANNEL_ID"
+	EnvChannelSecret = "LINE_PAY_CHANNEL_SECRET"
+)
+
+type envCredentialsProvider struct {
+	idKey, secretKey string
+}
+
+// NewEnvCredentialsProvider returns a CredentialsProvider that reads the environment on every call.
+// Empty keys default to EnvChannelID and EnvChannelSecret.
+func NewEnvCredentialsProvider(idKey, secretKey string) CredentialsProvider {
+	if idKey == "" {
+		idKey = EnvChannelID
+	}
+	if secretKey == "" {
+		secretKey = EnvChannelSecret
+	}
+	return &envCredentialsProvider{idKey: idKey, secretKey: secretKey}
+}
+
+// Credentials method
+func (p *envCredentialsProvider) Credentials() (*Credentials, error) {
+	creds := &Credentials{ChannelID: os.Getenv(p.idKey), ChannelSecret: os.Getenv(p.secretKey)}
+	if err := creds.validate(); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// FileCredentialsProvider type
+// FileCredentialsProvider reads credentials from a JSON file such as
+// {"channelId": "...", "channelSecret": "..."} and reloads it when its
+// modification time changes. The file is checked at most once per interval.
+type FileCredentialsProvider struct {
+	path     string
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	creds     *Credentials
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// NewFileCredentialsProvider returns a new file credentials provider instance.
+// The file must exist and hold valid credentials.
+func NewFileCredentialsProvider(path string, interval time.Duration) (*FileCredentialsProvider, error) {
+	p := &FileCredentialsProvider{path: path, interval: interval, now: time.Now}
+	if _, err := p.Credentials(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Credentials method
+// Credentials keeps returning the last good credentials if the file cannot be reloaded.
+func (p *FileCredentialsProvider) Credentials() (*Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if p.creds != nil && now.Sub(p.checkedAt) < p.interval {
+		creds := *p.creds
+		return &creds, nil
+	}
+	p.checkedAt = now
+
+	fi, err := os.Stat(p.path)
+	if err == nil && (p.creds == nil || !fi.ModTime().Equal(p.modTime)) {
+		err = p.load(fi.ModTime())
+	}
+	if p.creds == nil {
+		return nil, err
+	}
+	creds := *p.creds
+	return &creds, nil
+}
+
+func (p *FileCredentialsProvider) load(modTime time.Time) error {
+	b, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	creds := new(Credentials)
+	if err := json.Unmarshal(b, creds); err != nil {
+		return err
+	}
+	if err := creds.validate(); err != nil {
+		return err
+	}
+	p.creds = creds
+	p.modTime = modTime
+	return nil
+}
+
+func (c *Credentials) validate() error {
+	if c.ChannelID == "" {
+		return errors.New("missing channel id")
+	}
+	if c.ChannelSecret == "" {
+		return errors.New("missing channel secret")
+	}
+	return nil
+}
+
+// WithCredentialsProvider function
+// WithCredentialsProvider makes the client sign requests with credentials from p
+// instead of the channel id and secret passed to New, which may then be empty.
+func WithCredentialsProvider(p CredentialsProvider) ClientOption {
+	return func(client *Client) error {
+		client.credentials = p
+		return nil
+	}
+}
+
+// DefaultCredentialsGraceReturnCodes are the returnCodes retried with the previous credentials.
+var DefaultCredentialsGraceReturnCodes = []string{ReturnCodeHeaderError}
+
+// WithCredentialsGrace function
+// WithCredentialsGrace keeps the previous credentials for d after the provider
+// returns new ones. A request rejected with one of returnCodes during that window
+// is signed again with the previous credentials and sent once more, covering the
+// time until LINE Pay accepts the new secret. returnCodes default to
+// DefaultCredentialsGraceReturnCodes.
+func WithCredentialsGrace(d time.Duration, returnCodes ...string) ClientOption {
+	return func(client *Client) error {
+		if d < 0 {
+			return errors.New("negative credentials grace")
+		}
+		if len(returnCodes) == 0 {
+			returnCodes = DefaultCredentialsGraceReturnCodes
+		}
+		client.grace = &credentialsGrace{window: d, returnCodes: returnCodes, now: time.Now}
+		return nil
+	}
+}
+
+// credentialsGrace remembers the credentials replaced most recently.
+type credentialsGrace struct {
+	window      time.Duration
+	returnCodes []string
+	now         func() time.Time
+
+	mu        sync.Mutex
+	current   *Credentials
+	previous  *Credentials
+	rotatedAt time.Time
+}
+
+// observe records creds as the credentials in use.
+func (g *credentialsGrace) observe(creds *Credentials) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.current != nil && *g.current == *creds {
+		return
+	}
+	if g.current != nil {
+		g.previous = g.current
+		g.rotatedAt = g.now()
+	}
+	c := *creds
+	g.current = &c
+}
+
+// fallback returns the previous credentials if a request signed with used and
+// answered with body should be sent again with them.
+func (g *credentialsGrace) fallback(used *Credentials, body []byte) *Credentials {
+	code := peekReturnCode(body)
+	if code == "" || !containsString(g.returnCodes, code) {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.previous == nil || *g.previous == *used || g.now().Sub(g.rotatedAt) > g.window {
+		return nil
+	}
+	c := *g.previous
+	return &c
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
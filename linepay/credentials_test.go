@@ -0,0 +1,172 @@
+package linepay
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type rotatingProvider struct {
+	mu    sync.Mutex
+	creds Credentials
+}
+
+func (p *rotatingProvider) Credentials() (*Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	creds := p.creds
+	return &creds, nil
+}
+
+func (p *rotatingProvider) rotate(secret string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.creds.ChannelSecret = secret
+}
+
+func TestCredentialsGrace(t *testing.T) {
+	_, mux, serverURL, teardown := setup()
+	defer teardown()
+
+	// the server still only accepts the old secret
+	accepted := "old"
+	var signatures []string
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		probe := &http.Request{Header: http.Header{}}
+		sign(probe, &Credentials{ChannelID: "testid", ChannelSecret: accepted},
+			r.URL.Path+string(b)+r.Header.Get("X-LINE-Authorization-Nonce"))
+		got := r.Header.Get("X-LINE-Authorization")
+		signatures = append(signatures, got)
+		if got != probe.Header.Get("X-LINE-Authorization") {
+			fmt.Fprint(w, `{"returnCode":"1106","returnMessage":"Header information error"}`)
+			return
+		}
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	provider := &rotatingProvider{creds: Credentials{ChannelID: "testid", ChannelSecret: "old"}}
+	client, err := New("", "", WithEndpoint(serverURL), WithCredentialsProvider(provider), WithCredentialsGrace(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	client.grace.now = func() time.Time { return now }
+
+	confirm := func() string {
+		resp, _, err := client.Confirm(context.Background(), 1, &ConfirmRequest{Amount: 100, Currency: "JPY"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.ReturnCode
+	}
+
+	if code := confirm(); code != "0000" {
+		t.Fatalf("returnCode %s before rotation", code)
+	}
+
+	provider.rotate("new")
+	signatures = nil
+	if code := confirm(); code != "0000" {
+		t.Errorf("returnCode %s within grace; want 0000", code)
+	}
+	if len(signatures) != 2 || signatures[0] == signatures[1] {
+		t.Errorf("signatures %v; want a retry with another signature", signatures)
+	}
+
+	now = now.Add(2 * time.Minute)
+	signatures = nil
+	if code := confirm(); code != "1106" {
+		t.Errorf("returnCode %s after grace; want 1106", code)
+	}
+	if len(signatures) != 1 {
+		t.Errorf("%d requests after grace; want 1", len(signatures))
+	}
+
+	accepted = "new"
+	if code := confirm(); code != "0000" {
+		t.Errorf("returnCode %s with the new secret", code)
+	}
+}
+
+func TestFileCredentialsProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "linepay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "credentials.json")
+	write := func(secret string, modTime time.Time) {
+		if err := ioutil.WriteFile(path, []byte(`{"channelId":"id","channelSecret":"`+secret+`"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now()
+	write("one", start)
+	p, err := NewFileCredentialsProvider(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := start
+	p.now = func() time.Time { return now }
+
+	secret := func() string {
+		creds, err := p.Credentials()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return creds.ChannelSecret
+	}
+
+	write("two", start.Add(time.Minute))
+	if got := secret(); got != "one" {
+		t.Errorf("secret %s before interval; want one", got)
+	}
+	now = now.Add(2 * time.Second)
+	if got := secret(); got != "two" {
+		t.Errorf("secret %s after change; want two", got)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Second)
+	if got := secret(); got != "two" {
+		t.Errorf("secret %s after broken file; want two", got)
+	}
+
+	if _, err := NewFileCredentialsProvider(filepath.Join(dir, "missing.json"), time.Second); err == nil {
+		t.Error("NewFileCredentialsProvider returned no error for a missing file")
+	}
+}
+
+func TestEnvCredentialsProvider(t *testing.T) {
+	os.Setenv("TEST_LINE_PAY_ID", "id")
+	os.Setenv("TEST_LINE_PAY_SECRET", "secret")
+	defer os.Unsetenv("TEST_LINE_PAY_ID")
+	defer os.Unsetenv("TEST_LINE_PAY_SECRET")
+
+	p := NewEnvCredentialsProvider("TEST_LINE_PAY_ID", "TEST_LINE_PAY_SECRET")
+	creds, err := p.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.ChannelID != "id" || creds.ChannelSecret != "secret" {
+		t.Errorf("credentials %+v", creds)
+	}
+
+	os.Unsetenv("TEST_LINE_PAY_SECRET")
+	if _, err := p.Credentials(); err == nil {
+		t.Error("Credentials returned no error without a secret")
+	}
+}
@@ -0,0 +1,37 @@
+package linepay
+
+import "fmt"
+
+// ReturnCodeSuccess is the returnCode of a successful API call.
+const ReturnCodeSuccess = "0000"
+
+// returnCode constants
+const (
+	ReturnCodeHeaderError         = "1106"
+	ReturnCodeTransactionNotFound = "1150"
+	ReturnCodeOrderIDExists       = "1172"
+	ReturnCodeRegKeyNotFound      = "1190"
+	ReturnCodeRegKeyExpired       = "1193"
+	ReturnCodeInternalError       = "9000"
+)
+
+// Error type
+// Error represents an API call that LINE Pay answered with a non-success returnCode.
+type Error struct {
+	ReturnCode    string
+	ReturnMessage string
+}
+
+// Error method
+func (e *Error) Error() string {
+	return fmt.Sprintf("linepay: returnCode %s: %s", e.ReturnCode, e.ReturnMessage)
+}
+
+// CheckReturnCode function
+// CheckReturnCode returns *Error unless returnCode is ReturnCodeSuccess.
+func CheckReturnCode(returnCode, returnMessage string) error {
+	if returnCode == ReturnCodeSuccess {
+		return nil
+	}
+	return &Error{ReturnCode: returnCode, ReturnMessage: returnMessage}
+}
@@ -0,0 +1,99 @@
+package linepay
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Extra type
+// Extra holds the response fields the SDK does not model yet, keyed by their
+// path such as "info.newField" or "info[0].payInfo[1].newField".
+// It is nil when every field of the response is modelled.
+type Extra map[string]json.RawMessage
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unmarshalResponse decodes b into v, which must not have an UnmarshalJSON
+// method of its own, and collects the fields v does not model into extra.
+func unmarshalResponse(b []byte, v interface{}, extra *Extra) error {
+	if err := json.Unmarshal(b, v); err != nil {
+		return err
+	}
+	collected := Extra{}
+	collectExtra(b, reflect.TypeOf(v).Elem(), "", collected)
+	*extra = nil
+	if len(collected) > 0 {
+		*extra = collected
+	}
+	return nil
+}
+
+// collectExtra walks b along t and records the object keys t has no field for.
+func collectExtra(b []byte, t reflect.Type, path string, extra Extra) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(b, &object) != nil {
+			return
+		}
+		for key, value := range object {
+			field, ok := jsonField(t, key)
+			if !ok {
+				extra[joinPath(path, key)] = value
+				continue
+			}
+			collectExtra(value, field.Type, joinPath(path, key), extra)
+		}
+	case reflect.Slice, reflect.Array:
+		var array []json.RawMessage
+		if json.Unmarshal(b, &array) != nil {
+			return
+		}
+		for i, value := range array {
+			collectExtra(value, t.Elem(), path+"["+strconv.Itoa(i)+"]", extra)
+		}
+	}
+}
+
+// jsonField finds the field of t that encoding/json decodes key into.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var fold *reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if name == key {
+			return f, true
+		}
+		if fold == nil && strings.EqualFold(name, key) {
+			fold = &f
+		}
+	}
+	if fold != nil {
+		return *fold, true
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,48 @@
+package linepay
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestResponseExtra(t *testing.T) {
+	var confirm ConfirmResponse
+	if err := json.Unmarshal([]byte(`{
+		"returnCode": "0000",
+		"returnMessage": "OK",
+		"info": {"orderId": "o1", "transactionId": 1, "payInfo": []}
+	}`), &confirm); err != nil {
+		t.Fatal(err)
+	}
+	if confirm.Extra != nil {
+		t.Errorf("Extra %v; want nil", confirm.Extra)
+	}
+
+	var details PaymentDetailsResponse
+	if err := json.Unmarshal([]byte(`{
+		"returnCode": "0000",
+		"traceId": "abc",
+		"info": [{
+			"transactionId": 1,
+			"transactionDate": "2019-05-13T00:00:00Z",
+			"merchantReference": {"affiliateCode": "x"},
+			"payInfo": [{"method": "POINT", "amount": 10, "maskedCreditCardNumber": null}],
+			"refundList": [{"refundTransactionId":2, "refundAmount": -10, "reason": "r"}]
+		}]
+	}`), &details); err != nil {
+		t.Fatal(err)
+	}
+	want := Extra{
+		"traceId":                   json.RawMessage(`"abc"`),
+		"info[0].merchantReference": json.RawMessage(`{"affiliateCode": "x"}`),
+		"info[0].payInfo[0].maskedCreditCardNumber": json.RawMessage(`null`),
+		"info[0].refundList[0].reason":              json.RawMessage(`"r"`),
+	}
+	if !reflect.DeepEqual(details.Extra, want) {
+		t.Errorf("Extra %v; want %v", details.Extra, want)
+	}
+	if details.Info[0].PayInfo[0].Amount != 10 || details.Info[0].RefundList[0].RefundAmount != -10 {
+		t.Errorf("modelled fields were not decoded: %+v", details.Info[0])
+	}
+}
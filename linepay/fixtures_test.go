@@ -0,0 +1,256 @@
+package linepay
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fixture is a documented exchange with an endpoint, stored in testdata/<endpoint>/<case>.json.
+type fixture struct {
+	TransactionID int64           `json:"transactionId"`
+	RegKey        string          `json:"regKey"`
+	Request       json.RawMessage `json:"request"`
+	Method        string          `json:"method"`
+	Path          string          `json:"path"`
+	// Query is the expected raw query of GET requests.
+	Query string `json:"query"`
+	// Body is the expected JSON body of POST requests.
+	Body     json.RawMessage `json:"body"`
+	Response json.RawMessage `json:"response"`
+}
+
+// fixtureCalls call the endpoint of a testdata directory with the request of a fixture.
+var fixtureCalls = map[string]func(ctx context.Context, c *Client, f *fixture) (interface{}, error){
+	"request": func(ctx context.Context, c *Client, f *fixture) (interface{}, error) {
+		req := new(RequestRequest)
+		if err := json.Unmarshal(f.Request, req); err != nil {
+			return nil, err
+		}
+		resp, _, err := c.Request(ctx, req)
+		return resp, err
+	},
+	"confirm": func(ctx context.Context, c *Client, f *fixture) (interface{}, error) {
+		req := new(ConfirmRequest)
+		if err := json.Unmarshal(f.Request, req); err != nil {
+			return nil, err
+		}
+		resp, _, err := c.Confirm(ctx, f.TransactionID, req)
+		return resp, err
+	},
+	"capture": func(ctx context.Context, c *Client, f *fixture) (interface{}, error) {
+		req := new(CaptureRequest)
+		if err := json.Unmarshal(f.Request, req); err != nil {
+			return nil, err
+		}
+		resp, _, err := c.Capture(ctx, f.TransactionID, req)
+		return resp, err
+	},
+	"void": func(ctx context.Context, c *Client, f *fixture) (interface{}, error) {
+		req := new(VoidRequest)
+		if err := json.Unmarshal(f.Request, req); err != nil {
+			return nil, err
+		}
+		resp, _, err := c.Void(ctx, f.TransactionID, req)
+		return resp, err
+	},
+	"refund": func(ctx context.Context, c *Client, f *fixture) (interface{}, error) {
+		req := new(RefundRequest)
+		if err := json.Unmarshal(f.Request, req); err != nil {
+			return nil, err
+		}
+		resp, _, err := c.Refund(ctx, f.TransactionID, req)
+		return resp, err
+	},
+	"payment_details": func(ctx context.Context, c *Client, f *fixture) (interface{}, error) {
+		req := new(PaymentDetailsRequest)
+		if err := json.Unmarshal(f.Request, req); err != nil {
+			return nil, err
+		}
+		resp, _, err := c.PaymentDetails(ctx, req)
+		return resp, err
+	},
+	"check_payment_status": func(ctx context.Context, c *Client, f *fixture) (interface{}, error) {
+		req := new(CheckPaymentStatusRequest)
+		if err := json.Unmarshal(f.Request, req); err != nil {
+			return nil, err
+		}
+		resp, _, err := c.CheckPaymentStatus(ctx, f.TransactionID, req)
+		return resp, err
+	},
+	"pay_preapproved": func(ctx context.Context, c *Client, f *fixture) (interface{}, error) {
+		req := new(PayPreapprovedRequest)
+		if err := json.Unmarshal(f.Request, req); err != nil {
+			return nil, err
+		}
+		resp, _, err := c.PayPreapproved(ctx, f.RegKey, req)
+		return resp, err
+	},
+	"check_regkey": func(ctx context.Context, c *Client, f *fixture) (interface{}, error) {
+		req := new(CheckRegKeyRequest)
+		if err := json.Unmarshal(f.Request, req); err != nil {
+			return nil, err
+		}
+		resp, _, err := c.CheckRegKey(ctx, f.RegKey, req)
+		return resp, err
+	},
+	"expire_regkey": func(ctx context.Context, c *Client, f *fixture) (interface{}, error) {
+		req := new(ExpireRegKeyRequest)
+		if err := json.Unmarshal(f.Request, req); err != nil {
+			return nil, err
+		}
+		resp, _, err := c.ExpireRegKey(ctx, f.RegKey, req)
+		return resp, err
+	},
+}
+
+func TestFixtures(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints := map[string]bool{}
+	for _, path := range paths {
+		endpoint := filepath.Base(filepath.Dir(path))
+		endpoints[endpoint] = true
+		name := endpoint + "/" + strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(name, func(t *testing.T) {
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := new(fixture)
+			if err := json.Unmarshal(b, f); err != nil {
+				t.Fatal(err)
+			}
+			call, ok := fixtureCalls[endpoint]
+			if !ok {
+				t.Fatalf("no call for endpoint %s", endpoint)
+			}
+			testFixture(t, f, call)
+		})
+	}
+	for endpoint := range fixtureCalls {
+		if !endpoints[endpoint] {
+			t.Errorf("no fixtures for endpoint %s", endpoint)
+		}
+	}
+}
+
+func testFixture(t *testing.T, f *fixture, call func(context.Context, *Client, *fixture) (interface{}, error)) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	served := false
+	mux.HandleFunc(f.Path, func(w http.ResponseWriter, r *http.Request) {
+		served = true
+		if r.Method != f.Method {
+			t.Errorf("method %s; want %s", r.Method, f.Method)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+
+		signed := r.URL.Path
+		if r.Method == http.MethodGet {
+			if r.URL.RawQuery != f.Query {
+				t.Errorf("query %q; want %q", r.URL.RawQuery, f.Query)
+			}
+			signed += r.URL.RawQuery
+		} else {
+			if !jsonEqual(body, f.Body) {
+				t.Errorf("body %s; want %s", body, f.Body)
+			}
+			signed += string(body)
+		}
+		nonce := r.Header.Get("X-LINE-Authorization-Nonce")
+		if nonce == "" {
+			t.Error("missing nonce")
+		}
+		hash := hmac.New(sha256.New, []byte("testsecret"))
+		hash.Write([]byte("testsecret" + signed + nonce))
+		if got, want := r.Header.Get("X-LINE-Authorization"), base64.StdEncoding.EncodeToString(hash.Sum(nil)); got != want {
+			t.Errorf("signature %s; want %s over %q", got, want, signed+nonce)
+		}
+		if got := r.Header.Get("X-LINE-ChannelId"); got != "testid" {
+			t.Errorf("channel id %q; want testid", got)
+		}
+		w.Write(f.Response)
+	})
+
+	resp, err := call(context.Background(), client, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !served {
+		t.Fatalf("%s %s was not called", f.Method, f.Path)
+	}
+
+	// every documented field is decoded: nothing is left over, and encoding the
+	// response again reproduces each field of the fixture
+	if extra := reflect.ValueOf(resp).Elem().FieldByName("Extra").Interface().(Extra); extra != nil {
+		t.Errorf("unmodelled fields %v", extra)
+	}
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want interface{}
+	decodeNumbers(encoded, &got)
+	decodeNumbers(f.Response, &want)
+	if diff := subset(want, got, ""); diff != "" {
+		t.Errorf("decoded response differs at %s\n got %s\nwant %s", diff, encoded, f.Response)
+	}
+}
+
+func decodeNumbers(b []byte, v interface{}) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	d.Decode(v)
+}
+
+func jsonEqual(a, b []byte) bool {
+	var x, y interface{}
+	decodeNumbers(a, &x)
+	decodeNumbers(b, &y)
+	return reflect.DeepEqual(x, y)
+}
+
+// subset returns the path of the first value of want that is not in got, or "".
+func subset(want, got interface{}, path string) string {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return path
+		}
+		for key, value := range w {
+			if diff := subset(value, g[key], path+"."+key); diff != "" {
+				return diff
+			}
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return path
+		}
+		for i := range w {
+			if diff := subset(w[i], g[i], fmt.Sprintf("%s[%d]", path, i)); diff != "" {
+				return diff
+			}
+		}
+	default:
+		if !reflect.DeepEqual(want, got) {
+			return path
+		}
+	}
+	return ""
+}
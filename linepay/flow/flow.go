@@ -0,0 +1,265 @@
+// Package flow provides http.Handlers for the redirect based LINE Pay payment flow.
+//
+// The request handler calls Request with the order built by the application,
+// remembers the pending transaction in a TransactionStore and redirects the
+// user to LINE Pay. The confirm handler is the confirmUrl: it loads the pending
+// transaction, calls Confirm with the stored amount and hands the result to the
+// application. The cancel handler is the cancelUrl.
+//
+//	h, err := flow.New(pay, flow.NewMemoryStore(), buildOrder, flow.WithSuccessFunc(fulfill))
+//	http.Handle("/pay/", http.StripPrefix("/pay", h))
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+// Errors
+var (
+	ErrTransactionNotFound = errors.New("flow: transaction not found")
+	ErrOrderMismatch       = errors.New("flow: orderId does not match the pending transaction")
+	ErrInvalidAmount       = errors.New("flow: pending transaction has no valid amount")
+)
+
+// Transaction type
+// Transaction is a payment that was requested but not yet confirmed.
+type Transaction struct {
+	TransactionID int64            `json:"transactionId"`
+	OrderID       string           `json:"orderId"`
+	Amount        int              `json:"amount"`
+	Currency      linepay.Currency `json:"currency"`
+	CreatedAt     time.Time        `json:"createdAt"`
+}
+
+// BuildOrderFunc type
+// BuildOrderFunc builds the Request API body for the incoming request.
+type BuildOrderFunc func(r *http.Request) (*linepay.RequestRequest, error)
+
+// SuccessFunc type
+// SuccessFunc fulfills a confirmed order and writes the response.
+type SuccessFunc func(w http.ResponseWriter, r *http.Request, tx *Transaction, resp *linepay.ConfirmResponse)
+
+// CancelFunc type
+// CancelFunc writes the response for a payment cancelled by the user. tx is nil if it was not found.
+type CancelFunc func(w http.ResponseWriter, r *http.Request, tx *Transaction)
+
+// FailureFunc type
+// FailureFunc writes the response for a failed step. err is a *linepay.Error for non-success returnCodes.
+type FailureFunc func(w http.ResponseWriter, r *http.Request, err error)
+
+// Handler type
+type Handler struct {
+	client     *linepay.Client
+	store      TransactionStore
+	buildOrder BuildOrderFunc
+	onSuccess  SuccessFunc
+	onCancel   CancelFunc
+	onFailure  FailureFunc
+	now        func() time.Time
+}
+
+// Option type
+type Option func(*Handler) error
+
+// New returns a new payment flow handler instance.
+func New(client *linepay.Client, store TransactionStore, buildOrder BuildOrderFunc, options ...Option) (*Handler, error) {
+	if client == nil {
+		return nil, errors.New("missing client")
+	}
+	if store == nil {
+		return nil, errors.New("missing transaction store")
+	}
+	if buildOrder == nil {
+		return nil, errors.New("missing build order func")
+	}
+	h := &Handler{
+		client:     client,
+		store:      store,
+		buildOrder: buildOrder,
+		onSuccess:  defaultSuccess,
+		onCancel:   defaultCancel,
+		onFailure:  defaultFailure,
+		now:        time.Now,
+	}
+	for _, option := range options {
+		err := option(h)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// WithSuccessFunc function
+func WithSuccessFunc(f SuccessFunc) Option {
+	return func(h *Handler) error {
+		h.onSuccess = f
+		return nil
+	}
+}
+
+// WithCancelFunc function
+func WithCancelFunc(f CancelFunc) Option {
+	return func(h *Handler) error {
+		h.onCancel = f
+		return nil
+	}
+}
+
+// WithFailureFunc function
+func WithFailureFunc(f FailureFunc) Option {
+	return func(h *Handler) error {
+		h.onFailure = f
+		return nil
+	}
+}
+
+func defaultSuccess(w http.ResponseWriter, r *http.Request, tx *Transaction, resp *linepay.ConfirmResponse) {
+	fmt.Fprintf(w, "payment %s completed\n", tx.OrderID)
+}
+
+func defaultCancel(w http.ResponseWriter, r *http.Request, tx *Transaction) {
+	fmt.Fprintln(w, "payment cancelled")
+}
+
+func defaultFailure(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	if err == ErrTransactionNotFound || err == ErrOrderMismatch {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// ServeHTTP method
+// ServeHTTP routes /request, /confirm and /cancel. Mount it with http.StripPrefix.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/request":
+		h.Request(w, r)
+	case "/confirm":
+		h.Confirm(w, r)
+	case "/cancel":
+		h.Cancel(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Request method
+// Request requests the payment built by BuildOrderFunc and redirects to the LINE Pay payment page.
+func (h *Handler) Request(w http.ResponseWriter, r *http.Request) {
+	req, err := h.buildOrder(r)
+	if err != nil {
+		h.onFailure(w, r, err)
+		return
+	}
+	resp, _, err := h.client.Request(r.Context(), req)
+	if err != nil {
+		h.onFailure(w, r, err)
+		return
+	}
+	if err := linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage); err != nil {
+		h.onFailure(w, r, err)
+		return
+	}
+	tx := &Transaction{
+		TransactionID: resp.Info.TransactionID,
+		OrderID:       req.OrderID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		CreatedAt:     h.now(),
+	}
+	if err := h.store.Save(w, r, tx); err != nil {
+		h.onFailure(w, r, err)
+		return
+	}
+	http.Redirect(w, r, resp.Info.PaymentURL.Web, http.StatusFound)
+}
+
+// Confirm method
+// Confirm completes the payment LINE Pay redirected back with, using the amount stored at Request time.
+// Confirm is not cancelled when the user leaves the page, as the payment may
+// already be captured; it is bounded by the Confirm timeout of the client instead.
+// Once LINE Pay returned success, the success func is called even if the
+// pending transaction could not be deleted from the store.
+func (h *Handler) Confirm(w http.ResponseWriter, r *http.Request) {
+	tx, err := h.load(r)
+	if err != nil {
+		h.onFailure(w, r, err)
+		return
+	}
+	if tx.Amount <= 0 || tx.Currency == "" {
+		h.onFailure(w, r, ErrInvalidAmount)
+		return
+	}
+	resp, _, err := h.client.Confirm(detach(r.Context()), tx.TransactionID, &linepay.ConfirmRequest{
+		Amount:   tx.Amount,
+		Currency: tx.Currency,
+	})
+	if err != nil {
+		h.onFailure(w, r, err)
+		return
+	}
+	if err := linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage); err != nil {
+		h.onFailure(w, r, err)
+		return
+	}
+	// A transaction left in the store can only be confirmed again, which LINE Pay rejects.
+	_ = h.store.Delete(w, r, tx.TransactionID)
+	h.onSuccess(w, r, tx, resp)
+}
+
+// Cancel method
+// Cancel forgets the pending transaction of a payment the user cancelled on LINE Pay.
+func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request) {
+	tx, err := h.load(r)
+	switch err {
+	case nil:
+		if err := h.store.Delete(w, r, tx.TransactionID); err != nil {
+			h.onFailure(w, r, err)
+			return
+		}
+	case ErrTransactionNotFound, ErrOrderMismatch:
+		tx = nil
+	default:
+		h.onFailure(w, r, err)
+		return
+	}
+	h.onCancel(w, r, tx)
+}
+
+// load returns the pending transaction named by the transactionId and orderId query parameters.
+func (h *Handler) load(r *http.Request) (*Transaction, error) {
+	q := r.URL.Query()
+	transactionID, err := linepay.ParseInt64(q.Get("transactionId"))
+	if err != nil {
+		return nil, ErrTransactionNotFound
+	}
+	tx, err := h.store.Load(r, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if orderID := q.Get("orderId"); orderID != "" && orderID != tx.OrderID {
+		return nil, ErrOrderMismatch
+	}
+	return tx, nil
+}
+
+// detachedContext keeps the values of its parent but not its deadline or cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func detach(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
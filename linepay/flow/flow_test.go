@@ -0,0 +1,191 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+func setup(t *testing.T, store TransactionStore, options ...Option) (*Handler, *http.ServeMux, func()) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client, err := linepay.New("testid", "testsecret", linepay.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buildOrder := func(r *http.Request) (*linepay.RequestRequest, error) {
+		return &linepay.RequestRequest{
+			Amount:   250,
+			Currency: "JPY",
+			OrderID:  "order",
+		}, nil
+	}
+	h, err := New(client, store, buildOrder, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, mux, server.Close
+}
+
+// serve runs h for target and carries cookies over from the previous response.
+func serve(h http.Handler, target string, prev *httptest.ResponseRecorder) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if prev != nil {
+		for _, c := range prev.Result().Cookies() {
+			r.AddCookie(c)
+		}
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	for name, store := range map[string]TransactionStore{
+		"memory": NewMemoryStore(),
+		"cookie": NewCookieStore([]byte("0123456789abcdef0123456789abcdef")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var confirmed *Transaction
+			h, mux, teardown := setup(t, store, WithSuccessFunc(func(w http.ResponseWriter, r *http.Request, tx *Transaction, resp *linepay.ConfirmResponse) {
+				confirmed = tx
+			}))
+			defer teardown()
+
+			mux.HandleFunc("/v3/payments/request", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"returnCode":"0000","info":{"transactionId":1,"paymentUrl":{"web":"https://pay.example/1"}}}`)
+			})
+			mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+				v := new(linepay.ConfirmRequest)
+				json.NewDecoder(r.Body).Decode(v)
+				if v.Amount != 250 || v.Currency != "JPY" {
+					t.Errorf("Request body = %+v", v)
+				}
+				fmt.Fprint(w, `{"returnCode":"0000","info":{"orderId":"order","transactionId":1,"payInfo":[{"method":"BALANCE","amount":250}]}}`)
+			})
+
+			w := serve(h, "/request", nil)
+			if w.Code != http.StatusFound || w.Header().Get("Location") != "https://pay.example/1" {
+				t.Fatalf("request responded %d %q", w.Code, w.Header().Get("Location"))
+			}
+			w = serve(h, "/confirm?transactionId=1&orderId=order", w)
+			if confirmed == nil || confirmed.OrderID != "order" {
+				t.Fatalf("confirmed %+v; body %s", confirmed, w.Body)
+			}
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, c := range w.Result().Cookies() {
+				r.AddCookie(c)
+			}
+			if _, err := store.Load(r, 1); err != ErrTransactionNotFound {
+				t.Errorf("Load after confirm returned %v; want %v", err, ErrTransactionNotFound)
+			}
+		})
+	}
+}
+
+func TestHandler_ConfirmInvalidAmount(t *testing.T) {
+	var failure error
+	store := NewMemoryStore()
+	h, mux, teardown := setup(t, store, WithFailureFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+		failure = err
+	}))
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Confirm called for a transaction without amount")
+	})
+	store.Save(nil, nil, &Transaction{TransactionID: 1, OrderID: "order", Currency: "JPY"})
+
+	serve(h, "/confirm?transactionId=1&orderId=order", nil)
+	if failure != ErrInvalidAmount {
+		t.Errorf("failure %v; want %v", failure, ErrInvalidAmount)
+	}
+}
+
+func TestHandler_ConfirmClientGone(t *testing.T) {
+	var confirmed *Transaction
+	store := NewMemoryStore()
+	h, mux, teardown := setup(t, store, WithSuccessFunc(func(w http.ResponseWriter, r *http.Request, tx *Transaction, resp *linepay.ConfirmResponse) {
+		confirmed = tx
+	}))
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000","info":{"orderId":"order","transactionId":1}}`)
+	})
+	store.Save(nil, nil, &Transaction{TransactionID: 1, OrderID: "order", Amount: 250, Currency: "JPY"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest(http.MethodGet, "/confirm?transactionId=1&orderId=order", nil).WithContext(ctx)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if confirmed == nil {
+		t.Error("payment not confirmed after the user left")
+	}
+}
+
+// failingDeleteStore is a MemoryStore whose Delete always fails.
+type failingDeleteStore struct {
+	*MemoryStore
+}
+
+func (s failingDeleteStore) Delete(w http.ResponseWriter, r *http.Request, transactionID int64) error {
+	return errors.New("store unavailable")
+}
+
+func TestHandler_ConfirmDeleteError(t *testing.T) {
+	var confirmed *Transaction
+	var failure error
+	store := failingDeleteStore{NewMemoryStore()}
+	h, mux, teardown := setup(t, store,
+		WithSuccessFunc(func(w http.ResponseWriter, r *http.Request, tx *Transaction, resp *linepay.ConfirmResponse) {
+			confirmed = tx
+		}),
+		WithFailureFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+			failure = err
+		}),
+	)
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000","info":{"orderId":"order","transactionId":1}}`)
+	})
+	store.Save(nil, nil, &Transaction{TransactionID: 1, OrderID: "order", Amount: 250, Currency: "JPY"})
+
+	serve(h, "/confirm?transactionId=1&orderId=order", nil)
+	if confirmed == nil || failure != nil {
+		t.Errorf("confirmed %+v, failure %v; want success", confirmed, failure)
+	}
+}
+
+func TestHandler_ConfirmUnknownTransaction(t *testing.T) {
+	h, _, teardown := setup(t, NewMemoryStore())
+	defer teardown()
+
+	w := serve(h, "/confirm?transactionId=2", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("confirm responded %d; want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandler_ReturnCodeError(t *testing.T) {
+	var failure error
+	h, mux, teardown := setup(t, NewMemoryStore(), WithFailureFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+		failure = err
+	}))
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/request", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"1104","returnMessage":"merchant not found"}`)
+	})
+	serve(h, "/request", nil)
+	if e, ok := failure.(*linepay.Error); !ok || e.ReturnCode != "1104" {
+		t.Errorf("failure %v; want returnCode 1104", failure)
+	}
+}
@@ -0,0 +1,201 @@
+package flow
+
+import (
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/sessions"
+)
+
+// TransactionStore type
+// TransactionStore keeps pending transactions between the request and confirm handlers.
+// Stores that keep state on the client, such as SessionStore, write to w.
+type TransactionStore interface {
+	Save(w http.ResponseWriter, r *http.Request, tx *Transaction) error
+	// Load returns ErrTransactionNotFound for unknown transactions.
+	Load(r *http.Request, transactionID int64) (*Transaction, error)
+	Delete(w http.ResponseWriter, r *http.Request, transactionID int64) error
+}
+
+// MemoryStore type
+type MemoryStore struct {
+	mu           sync.Mutex
+	transactions map[int64]Transaction
+}
+
+// NewMemoryStore returns a new in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{transactions: make(map[int64]Transaction)}
+}
+
+// Save method
+func (s *MemoryStore) Save(w http.ResponseWriter, r *http.Request, tx *Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transactions[tx.TransactionID] = *tx
+	return nil
+}
+
+// Load method
+func (s *MemoryStore) Load(r *http.Request, transactionID int64) (*Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, ok := s.transactions[transactionID]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+	return &tx, nil
+}
+
+// Delete method
+func (s *MemoryStore) Delete(w http.ResponseWriter, r *http.Request, transactionID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.transactions, transactionID)
+	return nil
+}
+
+func init() {
+	gob.Register(&Transaction{})
+}
+
+// SessionStore type
+// SessionStore keeps pending transactions in a gorilla/sessions session, e.g. a signed and encrypted cookie.
+type SessionStore struct {
+	store sessions.Store
+	name  string
+}
+
+// NewSessionStore returns a new session store that uses the session called name.
+func NewSessionStore(store sessions.Store, name string) *SessionStore {
+	return &SessionStore{store: store, name: name}
+}
+
+// NewCookieStore returns a new session store backed by a cookie.
+// keyPairs are passed to sessions.NewCookieStore; give an encryption key so
+// that the amount cannot be read or altered by the user.
+func NewCookieStore(keyPairs ...[]byte) *SessionStore {
+	return NewSessionStore(sessions.NewCookieStore(keyPairs...), "linepay-transaction")
+}
+
+func sessionKey(transactionID int64) string {
+	return "tx:" + strconv.FormatInt(transactionID, 10)
+}
+
+// Save method
+func (s *SessionStore) Save(w http.ResponseWriter, r *http.Request, tx *Transaction) error {
+	session, err := s.store.Get(r, s.name)
+	if err != nil {
+		return err
+	}
+	session.Values[sessionKey(tx.TransactionID)] = tx
+	return session.Save(r, w)
+}
+
+// Load method
+func (s *SessionStore) Load(r *http.Request, transactionID int64) (*Transaction, error) {
+	session, err := s.store.Get(r, s.name)
+	if err != nil {
+		return nil, err
+	}
+	tx, ok := session.Values[sessionKey(transactionID)].(*Transaction)
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+	return tx, nil
+}
+
+// Delete method
+func (s *SessionStore) Delete(w http.ResponseWriter, r *http.Request, transactionID int64) error {
+	session, err := s.store.Get(r, s.name)
+	if err != nil {
+		return err
+	}
+	delete(session.Values, sessionKey(transactionID))
+	return session.Save(r, w)
+}
+
+// SQLStore type
+// SQLStore keeps pending transactions in a table created like:
+//
+//	CREATE TABLE linepay_transactions (
+//	    transaction_id BIGINT PRIMARY KEY,
+//	    order_id       VARCHAR(255) NOT NULL,
+//	    amount         INTEGER NOT NULL,
+//	    currency       VARCHAR(3) NOT NULL,
+//	    created_at     TIMESTAMP NOT NULL
+//	)
+type SQLStore struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+}
+
+// SQLOption type
+type SQLOption func(*SQLStore)
+
+// WithDollarPlaceholders function
+// WithDollarPlaceholders uses $1, $2, ... placeholders as required by PostgreSQL. Defaults to ?.
+func WithDollarPlaceholders() SQLOption {
+	return func(s *SQLStore) {
+		s.placeholder = func(n int) string { return "$" + strconv.Itoa(n) }
+	}
+}
+
+// NewSQLStore returns a new SQL store using table.
+func NewSQLStore(db *sql.DB, table string, options ...SQLOption) (*SQLStore, error) {
+	if db == nil {
+		return nil, errors.New("missing db")
+	}
+	if table == "" {
+		return nil, errors.New("missing table")
+	}
+	s := &SQLStore{
+		db:          db,
+		table:       table,
+		placeholder: func(int) string { return "?" },
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s, nil
+}
+
+// Save method
+func (s *SQLStore) Save(w http.ResponseWriter, r *http.Request, tx *Transaction) error {
+	q := fmt.Sprintf(
+		"INSERT INTO %s (transaction_id, order_id, amount, currency, created_at) VALUES (%s, %s, %s, %s, %s)",
+		s.table, s.placeholder(1), s.placeholder(2), s.placeholder(3), s.placeholder(4), s.placeholder(5),
+	)
+	_, err := s.db.ExecContext(r.Context(), q, tx.TransactionID, tx.OrderID, tx.Amount, tx.Currency, tx.CreatedAt)
+	return err
+}
+
+// Load method
+func (s *SQLStore) Load(r *http.Request, transactionID int64) (*Transaction, error) {
+	q := fmt.Sprintf(
+		"SELECT transaction_id, order_id, amount, currency, created_at FROM %s WHERE transaction_id = %s",
+		s.table, s.placeholder(1),
+	)
+	tx := new(Transaction)
+	err := s.db.QueryRowContext(r.Context(), q, transactionID).Scan(&tx.TransactionID, &tx.OrderID, &tx.Amount, &tx.Currency, &tx.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Delete method
+func (s *SQLStore) Delete(w http.ResponseWriter, r *http.Request, transactionID int64) error {
+	q := fmt.Sprintf("DELETE FROM %s WHERE transaction_id = %s", s.table, s.placeholder(1))
+	_, err := s.db.ExecContext(r.Context(), q, transactionID)
+	return err
+}
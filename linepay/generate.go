@@ -0,0 +1,3 @@
+package linepay
+
+//go:generate go run ./internal/cmd/genapi
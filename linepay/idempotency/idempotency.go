@@ -0,0 +1,184 @@
+// Package idempotency makes Request and Confirm safe to repeat.
+//
+// Calls are keyed by orderId (Request) or transactionId (Confirm). The first
+// call for a key is sent to LINE Pay; repeats while it is in flight fail with
+// ErrInFlight, and repeats after it succeeded return the stored response
+// without calling LINE Pay again.
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+// ErrInFlight is returned when the same operation is already being processed.
+var ErrInFlight = errors.New("idempotency: operation in flight")
+
+// Client type
+type Client struct {
+	client *linepay.Client
+	store  Store
+}
+
+// New returns a new idempotent client instance.
+func New(client *linepay.Client, store Store) (*Client, error) {
+	if client == nil {
+		return nil, errors.New("missing client")
+	}
+	if store == nil {
+		return nil, errors.New("missing store")
+	}
+	return &Client{client: client, store: store}, nil
+}
+
+// RequestKey returns the store key used by Request.
+func RequestKey(orderID string) string {
+	return "request:" + orderID
+}
+
+// ConfirmKey returns the store key used by Confirm.
+func ConfirmKey(transactionID int64) string {
+	return "confirm:" + strconv.FormatInt(transactionID, 10)
+}
+
+// Request method
+// Request calls linepay.Client.Request once per req.OrderID.
+// Responses with a non-success returnCode are not remembered, so the order can be requested again.
+func (c *Client) Request(ctx context.Context, req *linepay.RequestRequest) (*linepay.RequestResponse, error) {
+	key := RequestKey(req.OrderID)
+	resp := new(linepay.RequestResponse)
+	replayed, err := c.begin(ctx, key, resp)
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		return resp, nil
+	}
+
+	resp, _, err = c.client.Request(ctx, req)
+	if err != nil {
+		return nil, c.release(ctx, key, err)
+	}
+	if resp.ReturnCode != linepay.ReturnCodeSuccess {
+		return resp, c.release(ctx, key, nil)
+	}
+	return resp, c.complete(ctx, key, resp)
+}
+
+// Confirm method
+// Confirm calls linepay.Client.Confirm once per transactionID.
+// When the call fails without a response or with an ambiguous returnCode (see
+// linepay.AmbiguousReturnCode), PaymentDetails is consulted to find out
+// whether LINE Pay completed the payment anyway. If that cannot be
+// determined the operation stays in flight, and repeats fail with ErrInFlight
+// until the store expires it.
+func (c *Client) Confirm(ctx context.Context, transactionID int64, req *linepay.ConfirmRequest) (*linepay.ConfirmResponse, error) {
+	key := ConfirmKey(transactionID)
+	resp := new(linepay.ConfirmResponse)
+	replayed, err := c.begin(ctx, key, resp)
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		return resp, nil
+	}
+
+	resp, _, err = c.client.Confirm(ctx, transactionID, req)
+	if err != nil || linepay.AmbiguousReturnCode(resp.ReturnCode) {
+		cause := err
+		if cause == nil {
+			cause = linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage)
+		}
+		recovered, found, detailsErr := c.lookupConfirm(ctx, transactionID)
+		switch {
+		case detailsErr != nil:
+			return nil, fmt.Errorf("idempotency: confirm outcome unknown: %v (payment details: %v)", cause, detailsErr)
+		case found:
+			return recovered, c.complete(ctx, key, recovered)
+		case err != nil:
+			return nil, c.release(ctx, key, err)
+		default:
+			return resp, c.release(ctx, key, nil)
+		}
+	}
+	if resp.ReturnCode != linepay.ReturnCodeSuccess {
+		return resp, c.release(ctx, key, nil)
+	}
+	return resp, c.complete(ctx, key, resp)
+}
+
+// lookupConfirm rebuilds a ConfirmResponse from PaymentDetails. The payment is
+// found if linepay.ResolvedOutcome says it succeeded, not found if it says it
+// failed, and an error is returned if the outcome stays unknown.
+func (c *Client) lookupConfirm(ctx context.Context, transactionID int64) (*linepay.ConfirmResponse, bool, error) {
+	details, _, err := c.client.PaymentDetails(ctx, &linepay.PaymentDetailsRequest{
+		TransactionID: []int64{transactionID},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	switch details.ReturnCode {
+	case linepay.ReturnCodeSuccess:
+	case linepay.ReturnCodeTransactionNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, linepay.CheckReturnCode(details.ReturnCode, details.ReturnMessage)
+	}
+	for _, info := range details.Info {
+		if info.TransactionID != transactionID || info.OriginalTransactionID != 0 {
+			continue
+		}
+		switch linepay.ResolvedOutcome(info.PayStatus, len(info.RefundList) > 0) {
+		case linepay.OutcomeFailed:
+			return nil, false, nil
+		case linepay.OutcomeUnknown:
+			return nil, false, fmt.Errorf("payment found with payStatus %s and %d refunds", info.PayStatus, len(info.RefundList))
+		}
+		resp := &linepay.ConfirmResponse{
+			ReturnCode:    linepay.ReturnCodeSuccess,
+			ReturnMessage: details.ReturnMessage,
+		}
+		resp.Info.OrderID = info.OrderID
+		resp.Info.TransactionID = info.TransactionID
+		resp.Info.AuthorizationExpireDate = info.AuthorizationExpireDate
+		resp.Info.PayInfo = info.PayInfo
+		return resp, true, nil
+	}
+	return nil, false, nil
+}
+
+// begin reserves key. When a completed record exists its result is decoded into v and replayed is true.
+func (c *Client) begin(ctx context.Context, key string, v interface{}) (replayed bool, err error) {
+	rec, created, err := c.store.Begin(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if created {
+		return false, nil
+	}
+	if rec.State != StateCompleted {
+		return false, ErrInFlight
+	}
+	return true, json.Unmarshal(rec.Result, v)
+}
+
+func (c *Client) complete(ctx context.Context, key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.store.Complete(ctx, key, b)
+}
+
+// release forgets key and returns cause, or the release failure if there is no cause.
+func (c *Client) release(ctx context.Context, key string, cause error) error {
+	if err := c.store.Release(ctx, key); err != nil && cause == nil {
+		return err
+	}
+	return cause
+}
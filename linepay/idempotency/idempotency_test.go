@@ -0,0 +1,205 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+func setup(t *testing.T) (*Client, *http.ServeMux, func()) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client, err := linepay.New("testid", "testsecret", linepay.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(client, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, mux, server.Close
+}
+
+// dropConnection makes the client see a transport error.
+func dropConnection(w http.ResponseWriter) {
+	conn, _, _ := w.(http.Hijacker).Hijack()
+	conn.Close()
+}
+
+func TestClient_ConfirmReplays(t *testing.T) {
+	client, mux, teardown := setup(t)
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"returnCode":"0000","info":{"orderId":"order","transactionId":1}}`)
+	})
+
+	ctx := context.Background()
+	req := &linepay.ConfirmRequest{Amount: 100, Currency: "JPY"}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Confirm(ctx, 1, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Info.OrderID != "order" {
+			t.Errorf("OrderID %q; want %q", resp.Info.OrderID, "order")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Confirm called %d times; want 1", calls)
+	}
+}
+
+func TestClient_ConfirmInFlight(t *testing.T) {
+	client, _, teardown := setup(t)
+	defer teardown()
+
+	ctx := context.Background()
+	if _, _, err := client.store.Begin(ctx, ConfirmKey(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Confirm(ctx, 1, &linepay.ConfirmRequest{}); err != ErrInFlight {
+		t.Errorf("Confirm returned %v; want %v", err, ErrInFlight)
+	}
+}
+
+func TestClient_ConfirmRecoversFromPaymentDetails(t *testing.T) {
+	client, mux, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		dropConnection(w)
+	})
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Query().Get("transactionId"), "1"; got != want {
+			t.Errorf("transactionId %q; want %q", got, want)
+		}
+		fmt.Fprint(w, `{"returnCode":"0000","info":[{"transactionId":1,"orderId":"order","payStatus":"CAPTURE","payInfo":[{"method":"BALANCE","amount":100}]}]}`)
+	})
+
+	resp, err := client.Confirm(context.Background(), 1, &linepay.ConfirmRequest{Amount: 100, Currency: "JPY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReturnCode != linepay.ReturnCodeSuccess || resp.Info.OrderID != "order" || len(resp.Info.PayInfo) != 1 {
+		t.Errorf("Confirm returned %+v", resp)
+	}
+}
+
+func TestClient_ConfirmNotFoundReleases(t *testing.T) {
+	client, mux, teardown := setup(t)
+	defer teardown()
+
+	fail := true
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			dropConnection(w)
+			return
+		}
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"1150","returnMessage":"not found"}`)
+	})
+
+	ctx := context.Background()
+	if _, err := client.Confirm(ctx, 1, &linepay.ConfirmRequest{}); err == nil {
+		t.Fatal("Confirm succeeded; want transport error")
+	}
+	fail = false
+	if _, err := client.Confirm(ctx, 1, &linepay.ConfirmRequest{}); err != nil {
+		t.Errorf("Confirm after release returned %v", err)
+	}
+}
+
+func TestClient_RequestFailureIsNotRemembered(t *testing.T) {
+	client, mux, teardown := setup(t)
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/v3/payments/request", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"returnCode":"9000"}`)
+	})
+
+	ctx := context.Background()
+	req := &linepay.RequestRequest{OrderID: "order"}
+	for i := 0; i < 2; i++ {
+		if _, err := client.Request(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Request called %d times; want 2", calls)
+	}
+}
+
+func TestClient_ConfirmInternalErrorRecovers(t *testing.T) {
+	client, mux, teardown := setup(t)
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"returnCode":"9000","returnMessage":"internal error"}`)
+	})
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000","info":[{"transactionId":1,"orderId":"order","payStatus":"CAPTURE"}]}`)
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		resp, err := client.Confirm(ctx, 1, &linepay.ConfirmRequest{Amount: 100, Currency: "JPY"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.ReturnCode != linepay.ReturnCodeSuccess || resp.Info.OrderID != "order" {
+			t.Errorf("Confirm returned %+v", resp)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("confirm called %d times; want 1", calls)
+	}
+}
+
+func TestClient_ConfirmLookupPayStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		info         string
+		wantErr      bool
+		wantInFlight bool
+	}{
+		{"voided", `{"transactionId":1,"payStatus":"VOIDED_AUTHORIZATION"}`, true, false},
+		{"expired", `{"transactionId":1,"payStatus":"EXPIRED_AUTHORIZATION"}`, true, false},
+		{"refunded", `{"transactionId":1,"payStatus":"CAPTURE","refundList":[{"refundTransactionId":2,"refundAmount":-100}]}`, true, true},
+		{"refund entry only", `{"transactionId":1,"payStatus":"CAPTURE","originalTransactionId":9}`, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, teardown := setup(t)
+			defer teardown()
+
+			mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+				dropConnection(w)
+			})
+			mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"returnCode":"0000","info":[%s]}`, tt.info)
+			})
+
+			ctx := context.Background()
+			if _, err := client.Confirm(ctx, 1, &linepay.ConfirmRequest{}); (err != nil) != tt.wantErr {
+				t.Fatalf("Confirm returned %v; want error %v", err, tt.wantErr)
+			}
+			_, err := client.Confirm(ctx, 1, &linepay.ConfirmRequest{})
+			if inFlight := err == ErrInFlight; inFlight != tt.wantInFlight {
+				t.Errorf("second Confirm returned %v; want in flight %v", err, tt.wantInFlight)
+			}
+		})
+	}
+}
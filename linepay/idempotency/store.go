@@ -0,0 +1,121 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State type
+type State string
+
+// State constants
+const (
+	StateInFlight  State = "IN_FLIGHT"
+	StateCompleted State = "COMPLETED"
+)
+
+// Record type
+type Record struct {
+	Key       string    `json:"key"`
+	State     State     `json:"state"`
+	Result    []byte    `json:"result,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Store type
+// Store remembers in-flight and completed operations. Implementations must be safe for concurrent use.
+type Store interface {
+	// Begin atomically creates an in-flight record for key and returns created=true.
+	// If a record already exists it is returned with created=false.
+	Begin(ctx context.Context, key string) (rec *Record, created bool, err error)
+	// Complete stores the result of key and marks it completed.
+	Complete(ctx context.Context, key string, result []byte) error
+	// Release removes key so that the operation can be attempted again.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryStore type
+type MemoryStore struct {
+	mu          sync.Mutex
+	records     map[string]Record
+	inFlightTTL time.Duration
+	ttl         time.Duration
+	now         func() time.Time
+}
+
+// MemoryStoreOption type
+type MemoryStoreOption func(*MemoryStore)
+
+// WithInFlightTTL function
+// WithInFlightTTL sets how long an in-flight record blocks repeats, e.g. after the process crashed mid-call.
+// Defaults to 10 minutes.
+func WithInFlightTTL(d time.Duration) MemoryStoreOption {
+	return func(s *MemoryStore) {
+		s.inFlightTTL = d
+	}
+}
+
+// WithTTL function
+// WithTTL sets how long completed records are kept. Defaults to 24 hours.
+func WithTTL(d time.Duration) MemoryStoreOption {
+	return func(s *MemoryStore) {
+		s.ttl = d
+	}
+}
+
+// NewMemoryStore returns a new in-memory store.
+func NewMemoryStore(options ...MemoryStoreOption) *MemoryStore {
+	s := &MemoryStore{
+		records:     make(map[string]Record),
+		inFlightTTL: 10 * time.Minute,
+		ttl:         24 * time.Hour,
+		now:         time.Now,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Begin method
+func (s *MemoryStore) Begin(ctx context.Context, key string) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if rec, ok := s.records[key]; ok && !s.expired(rec, now) {
+		return &rec, false, nil
+	}
+	rec := Record{Key: key, State: StateInFlight, UpdatedAt: now}
+	s.records[key] = rec
+	return &rec, true, nil
+}
+
+// Complete method
+func (s *MemoryStore) Complete(ctx context.Context, key string, result []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = Record{
+		Key:       key,
+		State:     StateCompleted,
+		Result:    append([]byte(nil), result...),
+		UpdatedAt: s.now(),
+	}
+	return nil
+}
+
+// Release method
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func (s *MemoryStore) expired(rec Record, now time.Time) bool {
+	ttl := s.ttl
+	if rec.State == StateInFlight {
+		ttl = s.inFlightTTL
+	}
+	return now.Sub(rec.UpdatedAt) > ttl
+}
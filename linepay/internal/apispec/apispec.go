@@ -0,0 +1,368 @@
+// Package apispec describes the LINE Pay endpoints wrapped by package linepay.
+// The methods, request and response types and enums of package linepay are
+// generated from it by internal/cmd/genapi; run go generate after changing it.
+package apispec
+
+// Endpoint type
+type Endpoint struct {
+	// Name is the name of the method; the request and response types are Name+"Request" and Name+"Response".
+	Name string
+	// File is the file the endpoint is generated into.
+	File   string
+	Method string
+	// Internal generates the method unexported, for a hand-written method of Name to wrap.
+	Internal bool
+	// Path holds one {name} placeholder per Param.
+	Path   string
+	Params []Param
+	Doc    []string
+	// Types are named types used by Request, declared before it.
+	Types    []*Struct
+	Request  []Field
+	Response []Field
+}
+
+// Param type
+type Param struct {
+	// Name is the Go parameter name, Placeholder the name in Path.
+	Name        string
+	Placeholder string
+	Type        string
+}
+
+// Struct type
+type Struct struct {
+	Name   string
+	Fields []Field
+}
+
+// Field type
+type Field struct {
+	Name string
+	// Type is the Go type. It is empty for an anonymous struct of Fields.
+	Type string
+	// Slice makes an anonymous struct a slice of it.
+	Slice  bool
+	Fields []Field
+	// JSON and Query are the json and url tags.
+	JSON  string
+	Query string
+	// Comment is written on the lines before the field.
+	Comment string
+}
+
+// Enum type
+type Enum struct {
+	Name   string
+	Doc    string
+	Values []EnumValue
+}
+
+// EnumValue type
+type EnumValue struct {
+	Name  string
+	Value string
+}
+
+// Enums are the typed string values used in the requests and responses.
+var Enums = []Enum{
+	{Name: "Currency", Doc: "ISO 4217 currency code", Values: []EnumValue{
+		{"CurrencyJPY", "JPY"}, {"CurrencyTWD", "TWD"}, {"CurrencyTHB", "THB"}, {"CurrencyUSD", "USD"},
+	}},
+	{Name: "PayType", Doc: "options.payment.payType of Request", Values: []EnumValue{
+		{"PayTypeNormal", "NORMAL"}, {"PayTypePreapproved", "PREAPPROVED"},
+	}},
+	{Name: "ConfirmURLType", Doc: "redirectUrls.confirmUrlType of Request", Values: []EnumValue{
+		{"ConfirmURLTypeClient", "CLIENT"}, {"ConfirmURLTypeServer", "SERVER"}, {"ConfirmURLTypeNone", "NONE"},
+	}},
+	{Name: "ShippingType", Doc: "options.shipping.type of Request", Values: []EnumValue{
+		{"ShippingTypeNoShipping", "NO_SHIPPING"}, {"ShippingTypeFixedAddress", "FIXED_ADDRESS"}, {"ShippingTypeShipping", "SHIPPING"},
+	}},
+	{Name: "FeeInquiryType", Doc: "options.shipping.feeInquiryType of Request", Values: []EnumValue{
+		{"FeeInquiryTypeCondition", "CONDITION"}, {"FeeInquiryTypeFixed", "FIXED"},
+	}},
+	{Name: "TransactionType", Doc: "transactionType of PaymentDetails", Values: []EnumValue{
+		{"TransactionTypePayment", "PAYMENT"}, {"TransactionTypePaymentRefund", "PAYMENT_REFUND"}, {"TransactionTypePartialRefund", "PARTIAL_REFUND"},
+	}},
+	{Name: "PayStatus", Doc: "payStatus of PaymentDetails", Values: []EnumValue{
+		{"PayStatusCapture", "CAPTURE"}, {"PayStatusAuthorization", "AUTHORIZATION"},
+		{"PayStatusVoidedAuthorization", "VOIDED_AUTHORIZATION"}, {"PayStatusExpiredAuthorization", "EXPIRED_AUTHORIZATION"},
+	}},
+	{Name: "PayMethod", Doc: "payInfo[].method of the responses", Values: []EnumValue{
+		{"PayMethodCreditCard", "CREDIT_CARD"}, {"PayMethodBalance", "BALANCE"}, {"PayMethodDiscount", "DISCOUNT"}, {"PayMethodPoint", "POINT"},
+	}},
+}
+
+var (
+	transactionIDParam = Param{Name: "transactionID", Placeholder: "transactionId", Type: "int64"}
+	regKeyParam        = Param{Name: "regKey", Placeholder: "regKey", Type: "string"}
+
+	returnFields = []Field{
+		{Name: "ReturnCode", Type: "string", JSON: "returnCode"},
+		{Name: "ReturnMessage", Type: "string", JSON: "returnMessage"},
+	}
+
+	payInfo = Field{Name: "PayInfo", Slice: true, JSON: "payInfo", Fields: []Field{
+		{Name: "Method", Type: "PayMethod", JSON: "method"},
+		{Name: "Amount", Type: "int", JSON: "amount"},
+	}}
+)
+
+// withInfo returns the returnCode and returnMessage fields followed by an info object of fields.
+func withInfo(fields ...Field) []Field {
+	return append(returnFields[:len(returnFields):len(returnFields)], Field{Name: "Info", JSON: "info", Fields: fields})
+}
+
+// Endpoints are the wrapped endpoints.
+var Endpoints = []Endpoint{
+	{
+		Name:   "Request",
+		File:   "request.go",
+		Method: "POST",
+		Path:   "/v3/payments/request",
+		Doc: []string{
+			"LINE Pay決済をリクエストします。このとき、ユーザーの注文情報と決済手段を設定できます。",
+			"リクエストに成功するとLINE Pay取引番号が発行されます。この取引番号を利用して、決済完了・返金を行うことができます。",
+		},
+		Types: []*Struct{
+			{Name: "RequestPackageProduct", Fields: []Field{
+				{Name: "ID", Type: "string", JSON: "id,omitempty"},
+				{Name: "Name", Type: "string", JSON: "name"},
+				{Name: "ImageURL", Type: "string", JSON: "imageUrl,omitempty"},
+				{Name: "Quantity", Type: "int", JSON: "quantity"},
+				{Name: "Price", Type: "int", JSON: "price"},
+				{Name: "OriginalPrice", Type: "int", JSON: "originalPrice,omitempty"},
+			}},
+			{Name: "RequestPackage", Fields: []Field{
+				{Name: "ID", Type: "string", JSON: "id"},
+				{Name: "Amount", Type: "int", JSON: "amount"},
+				{Name: "UserFee", Type: "int", JSON: "userFee,omitempty"},
+				{Name: "Name", Type: "string", JSON: "name"},
+				{Name: "Products", Type: "[]*RequestPackageProduct", JSON: "products"},
+			}},
+			{Name: "RequestRedirectURLs", Fields: []Field{
+				{Name: "AppPackageName", Type: "string", JSON: "appPackageName,omitempty"},
+				{Name: "ConfirmURL", Type: "string", JSON: "confirmUrl"},
+				{Name: "ConfirmURLType", Type: "ConfirmURLType", JSON: "confirmUrlType,omitempty"},
+				{Name: "CancelURL", Type: "string", JSON: "cancelUrl"},
+			}},
+			{Name: "RequestOptionsPayment", Fields: []Field{
+				{Name: "Capture", Type: "*bool", JSON: "capture,omitempty"},
+				{Name: "PayType", Type: "PayType", JSON: "payType,omitempty"},
+			}},
+			{Name: "RequestOptionsDisplay", Fields: []Field{
+				{Name: "Locale", Type: "string", JSON: "locale,omitempty"},
+				{Name: "CheckConfirmURLBrowser", Type: "*bool", JSON: "checkConfirmUrlBrowser,omitempty"},
+			}},
+			{Name: "RequestOptionsShipping", Fields: []Field{
+				{Name: "Type", Type: "ShippingType", JSON: "type,omitempty"},
+				{Name: "FeeInquiryURL", Type: "string", JSON: "feeInquiryUrl,omitempty"},
+				{Name: "FeeInquiryType", Type: "FeeInquiryType", JSON: "feeInquiryType,omitempty"},
+			}},
+			{Name: "RequestOptionsExtras", Fields: []Field{
+				{Name: "FamilyService", JSON: "familyService,omitempty", Fields: []Field{
+					{Name: "AddFriends", Slice: true, JSON: "addFriends,omitempty", Fields: []Field{
+						{Name: "Type", Type: "string", JSON: "type,omitempty"},
+						{Name: "IDs", Type: "[]string", JSON: "ids,omitempty"},
+					}},
+				}},
+				{Name: "BranchName", Type: "string", JSON: "branchName,omitempty"},
+				{Name: "BranchID", Type: "string", JSON: "branchId,omitempty"},
+			}},
+			{Name: "RequestOptions", Fields: []Field{
+				{Name: "Payment", Type: "*RequestOptionsPayment", JSON: "payment,omitempty"},
+				{Name: "Display", Type: "*RequestOptionsDisplay", JSON: "display,omitempty"},
+				{Name: "Shipping", Type: "*RequestOptionsShipping", JSON: "shipping,omitempty"},
+				{Name: "Extras", Type: "*RequestOptionsExtras", JSON: "extras,omitempty"},
+			}},
+		},
+		Request: []Field{
+			{Name: "Amount", Type: "int", JSON: "amount"},
+			{Name: "Currency", Type: "Currency", JSON: "currency"},
+			{Name: "OrderID", Type: "string", JSON: "orderId"},
+			{Name: "Packages", Type: "[]*RequestPackage", JSON: "packages"},
+			{Name: "RedirectURLs", Type: "*RequestRedirectURLs", JSON: "redirectUrls"},
+			{Name: "Options", Type: "*RequestOptions", JSON: "options,omitempty"},
+		},
+		Response: withInfo(
+			Field{Name: "TransactionID", Type: "int64", JSON: "transactionId"},
+			Field{Name: "PaymentURL", JSON: "paymentUrl", Fields: []Field{
+				{Name: "Web", Type: "string", JSON: "web"},
+				{Name: "App", Type: "string", JSON: "app"},
+			}},
+			Field{Name: "PaymentAccessToken", Type: "string", JSON: "paymentAccessToken"},
+		),
+	},
+	{
+		Name:   "Confirm",
+		File:   "confirm.go",
+		Method: "POST",
+		Path:   "/v3/payments/{transactionId}/confirm",
+		Params: []Param{transactionIDParam},
+		Doc: []string{
+			"confirmUrlまたはCheck Payment Status APIによってユーザーが決済要求を承認した後、加盟店側で決済を完了させるためのAPIです。",
+			"Request APIの\"options.payment.capture\"をfalseに設定するとオーソリと売上確定が分離された決済になり、決済を完了させても決済ステータスは売上確定待ち(オーソリ)状態のままとなります。",
+			"売上を確定するには、Capture APIを呼び出して売上確定を行う必要があります。",
+		},
+		Request: []Field{
+			{Name: "Amount", Type: "int", JSON: "amount"},
+			{Name: "Currency", Type: "Currency", JSON: "currency"},
+		},
+		Response: withInfo(
+			Field{Name: "OrderID", Type: "string", JSON: "orderId"},
+			Field{Name: "TransactionID", Type: "int64", JSON: "transactionId"},
+			Field{Name: "AuthorizationExpireDate", Type: "string", JSON: "authorizationExpireDate,omitempty"},
+			Field{Name: "RegKey", Type: "string", JSON: "regKey,omitempty"},
+			payInfo,
+		),
+	},
+	{
+		Name:   "Capture",
+		File:   "capture.go",
+		Method: "POST",
+		Path:   "/v3/payments/authorizations/{transactionId}/capture",
+		Params: []Param{transactionIDParam},
+		Doc: []string{
+			"Request APIを使って決済をリクエストする際に\"options.payment.capture\"をfalseに設定した場合、Confirm APIで決済を完了させると決済ステータスは売上確定待ち状態になります。",
+			"決済を完全に確定するためには、Capture APIを呼び出して売上確定を行う必要があります。",
+		},
+		Request: []Field{
+			{Name: "Amount", Type: "int", JSON: "amount"},
+			{Name: "Currency", Type: "Currency", JSON: "currency"},
+		},
+		Response: withInfo(
+			Field{Name: "TransactionID", Type: "int64", JSON: "transactionId"},
+			Field{Name: "OrderID", Type: "string", JSON: "orderId"},
+			payInfo,
+		),
+	},
+	{
+		Name:   "Void",
+		File:   "void.go",
+		Method: "POST",
+		Path:   "/v3/payments/authorizations/{transactionId}/void",
+		Params: []Param{transactionIDParam},
+		Doc: []string{
+			"決済ステータスがオーソリ状態である決済データを無効化するAPIです。",
+			"Confirm APIを呼び出して決済完了したオーソリ状態の取引を取り消すことができます。",
+			"取り消しできるのはオーソリ状態の取引だけであり、売上確定済みの取引はRefund APIを使用して返金します。",
+		},
+		Response: withInfo(
+			Field{Name: "RefundTransactionID", Type: "int64", JSON: "refundTransactionId"},
+			Field{Name: "RefundTransactionDate", Type: "time.Time", JSON: "refundTransactionDate"},
+		),
+	},
+	{
+		Name:   "Refund",
+		File:   "refund.go",
+		Method: "POST",
+		Path:   "/v3/payments/{transactionId}/refund",
+		Params: []Param{transactionIDParam},
+		Doc: []string{
+			"決済完了(売上確定済み)された取引を返金します。",
+			"返金時は、LINE Payユーザーの決済取引番号を必ず渡す必要があります。一部返金も可能です。",
+		},
+		Request: []Field{
+			{Name: "RefundAmount", Type: "int", JSON: "refundAmount,omitempty"},
+		},
+		Response: withInfo(
+			Field{Name: "RefundTransactionID", Type: "int64", JSON: "refundTransactionId"},
+			Field{Name: "RefundTransactionDate", Type: "time.Time", JSON: "refundTransactionDate"},
+		),
+	},
+	{
+		Name:     "PaymentDetails",
+		File:     "payment_details.go",
+		Internal: true,
+		Method:   "GET",
+		Path:     "/v3/payments",
+		Doc: []string{
+			"LINE Payの取引履歴を照会するAPIです。オーソリと売上確定の取引を照会できます。",
+			"\"fields\"を設定することで、取引情報または注文情報を選択的に照会することができます。",
+		},
+		Request: []Field{
+			{Name: "TransactionID", Type: "[]int64", Query: "transactionId,omitempty"},
+			{Name: "OrderID", Type: "[]string", Query: "orderId,omitempty"},
+			{Name: "Fields", Type: "string", Query: "fields,omitempty"},
+		},
+		Response: append(returnFields[:len(returnFields):len(returnFields)], Field{Name: "Info", Slice: true, JSON: "info", Fields: []Field{
+			{Name: "TransactionID", Type: "int64", JSON: "transactionId"},
+			{Name: "OrderID", Type: "string", JSON: "orderId,omitempty"},
+			{Name: "TransactionDate", Type: "time.Time", JSON: "transactionDate"},
+			{Name: "TransactionType", Type: "TransactionType", JSON: "transactionType"},
+			{Name: "PayStatus", Type: "PayStatus", JSON: "payStatus"},
+			{Name: "ProductName", Type: "string", JSON: "productName"},
+			{Name: "MerchantName", Type: "string", JSON: "merchantName"},
+			{Name: "Currency", Type: "Currency", JSON: "currency"},
+			{Name: "AuthorizationExpireDate", Type: "string", JSON: "authorizationExpireDate"},
+			payInfo,
+			{Name: "RefundList", Slice: true, JSON: "refundList,omitempty", Comment: "原決済取引照会、および払い戻し取引がある場合", Fields: []Field{
+				{Name: "RefundTransactionID", Type: "int64", JSON: "refundTransactionId"},
+				{Name: "TransactionType", Type: "TransactionType", JSON: "transactionType"},
+				{Name: "RefundAmount", Type: "int", JSON: "refundAmount"},
+				{Name: "RefundTransactionDate", Type: "time.Time", JSON: "refundTransactionDate"},
+			}},
+			{Name: "OriginalTransactionID", Type: "int64", JSON: "originalTransactionId,omitempty", Comment: "払い戻し取引の照会の場合"},
+		}}),
+	},
+	{
+		Name:   "CheckPaymentStatus",
+		File:   "check_payment_status.go",
+		Method: "GET",
+		Path:   "/v3/payments/requests/{transactionId}/check",
+		Params: []Param{transactionIDParam},
+		Doc: []string{
+			"LINE Pay でのオーソリ履歴の内訳を照会する API です。オーソリ済み、またはオーソリ無効処理データのみ照会できます。売上が確",
+			"定されたデータは「決済内訳照会 API」で照会できます。",
+		},
+		Response: returnFields,
+	},
+	{
+		Name:   "PayPreapproved",
+		File:   "pay_preapproved.go",
+		Method: "POST",
+		Path:   "/v3/payments/preapprovedPay/{regKey}/payment",
+		Params: []Param{regKeyParam},
+		Doc: []string{
+			"決済 reserve API で決済タイプ(type)が PREAPPROVED で決済された場合、決済結果の受信時に regKey を受け取ります。",
+			"継続決済 API は、この regKey を利用し LINE アプリを介さずに直接決済する際に使用します。",
+		},
+		Request: []Field{
+			{Name: "ProductName", Type: "string", JSON: "productName"},
+			{Name: "Amount", Type: "int", JSON: "amount"},
+			{Name: "Currency", Type: "Currency", JSON: "currency"},
+			{Name: "OrderID", Type: "string", JSON: "orderId"},
+			{Name: "Capture", Type: "*bool", JSON: "capture,omitempty"},
+		},
+		Response: withInfo(
+			Field{Name: "TransactionID", Type: "int64", JSON: "transactionId"},
+			Field{Name: "TransactionDate", Type: "time.Time", JSON: "transactionDate"},
+		),
+	},
+	{
+		Name:   "CheckRegKey",
+		File:   "check_regkey.go",
+		Method: "GET",
+		Path:   "/v3/payments/preapprovedPay/{regKey}/check",
+		Params: []Param{regKeyParam},
+		Doc: []string{
+			"継続決済 API を使用する前に、regKey が使用可能な状態であるかどうかを確認します。",
+		},
+		Request: []Field{
+			{Name: "CreditCardAuth", Type: "*bool", Query: "creditCardAuth,omitempty"},
+		},
+		Response: returnFields,
+	},
+	{
+		Name:   "ExpireRegKey",
+		File:   "expire_regkey.go",
+		Method: "POST",
+		Path:   "/v3/payments/preapprovedPay/{regKey}/expire",
+		Params: []Param{regKeyParam},
+		Doc: []string{
+			"継続決済で登録された regKey 情報を満了させる API です。",
+			"この API を呼び出した以降は、当該の regKey では継続決済することができなくなります。",
+		},
+		Response: returnFields,
+	},
+}
@@ -0,0 +1,28 @@
+// Command genapi generates the endpoint methods, request and response types,
+// enums and endpoint tests of package linepay from package apispec.
+// It is run by go generate in the linepay directory.
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay/internal/gen"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of package linepay")
+	flag.Parse()
+
+	files, err := gen.Files()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(*dir, name), src, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Package gen renders the files of package linepay generated from package apispec.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay/internal/apispec"
+)
+
+const header = "// Code generated by genapi from internal/apispec; DO NOT EDIT.\n\n"
+
+// Files returns the generated files of package linepay, including the OpenAPI document openapi.json, keyed by file name.
+func Files() (map[string][]byte, error) {
+	files := map[string][]byte{
+		"enums.go":              generateEnums(apispec.Enums),
+		"endpoints_gen_test.go": generateTests(apispec.Endpoints),
+	}
+	for _, e := range apispec.Endpoints {
+		files[e.File] = generateEndpoint(e)
+	}
+	for name, src := range files {
+		formatted, err := format.Source(src)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		files[name] = formatted
+	}
+	doc, err := OpenAPI()
+	if err != nil {
+		return nil, err
+	}
+	files["openapi.json"] = doc
+	return files, nil
+}
+
+func generateEndpoint(e apispec.Endpoint) []byte {
+	var b bytes.Buffer
+	b.WriteString(header + "package linepay\n\nimport (\n\"context\"\n")
+	if len(e.Params) > 0 {
+		b.WriteString("\"fmt\"\n")
+	}
+	b.WriteString("\"net/http\"\n")
+	if usesTime(e) {
+		b.WriteString("\"time\"\n")
+	}
+	b.WriteString(")\n\n")
+
+	method := e.Name
+	if e.Internal {
+		method = strings.ToLower(method[:1]) + method[1:]
+		fmt.Fprintf(&b, "// %s method\n// %s sends a single request; %s is written by hand on top of it.\n", method, method, e.Name)
+	} else {
+		fmt.Fprintf(&b, "// %s method\n", method)
+		for _, line := range e.Doc {
+			fmt.Fprintf(&b, "// %s\n", line)
+		}
+	}
+	fmt.Fprintf(&b, "func (c *Client) %s(ctx context.Context, ", method)
+	for _, p := range e.Params {
+		fmt.Fprintf(&b, "%s %s, ", p.Name, p.Type)
+	}
+	fmt.Fprintf(&b, "req *%sRequest, options ...CallOption) (*%sResponse, *http.Response, error) {\n", e.Name, e.Name)
+	if len(e.Params) == 0 {
+		fmt.Fprintf(&b, "path := %q\n", e.Path)
+	} else {
+		path := e.Path
+		var args []string
+		for _, p := range e.Params {
+			verb := "%s"
+			if p.Type != "string" {
+				verb = "%d"
+			}
+			path = strings.Replace(path, "{"+p.Placeholder+"}", verb, 1)
+			args = append(args, p.Name)
+		}
+		fmt.Fprintf(&b, "path := fmt.Sprintf(%q, %s)\n", path, strings.Join(args, ", "))
+	}
+	fmt.Fprintf(&b, "resp := new(%sResponse)\n", e.Name)
+	fmt.Fprintf(&b, "httpResp, err := c.call(ctx, Operation%s, http.Method%s, path, req, resp, options)\n", e.Name, methodName(e.Method))
+	b.WriteString("if err != nil {\nreturn nil, httpResp, err\n}\nreturn resp, httpResp, nil\n}\n")
+
+	for _, s := range e.Types {
+		fmt.Fprintf(&b, "\n// %s type\ntype %s ", s.Name, s.Name)
+		writeStruct(&b, s.Fields)
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "\n// %sRequest type\ntype %sRequest ", e.Name, e.Name)
+	writeStruct(&b, e.Request)
+	b.WriteString("\n")
+
+	fields := append(e.Response[:len(e.Response):len(e.Response)], apispec.Field{
+		Name:    "Extra",
+		Type:    "Extra",
+		JSON:    "-",
+		Comment: "Extra holds the fields of the response the SDK does not model.",
+	})
+	fmt.Fprintf(&b, "\n// %sResponse type\ntype %sResponse ", e.Name, e.Name)
+	writeStruct(&b, fields)
+	b.WriteString("\n")
+
+	fmt.Fprintf(&b, `
+// UnmarshalJSON method
+func (r *%[1]sResponse) UnmarshalJSON(b []byte) error {
+	type response %[1]sResponse
+	return unmarshalResponse(b, (*response)(r), &r.Extra)
+}
+`, e.Name)
+	return b.Bytes()
+}
+
+func writeStruct(b *bytes.Buffer, fields []apispec.Field) {
+	b.WriteString("struct {\n")
+	for i, f := range fields {
+		if f.Comment != "" {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(b, "// %s\n", f.Comment)
+		}
+		b.WriteString(f.Name + " ")
+		if f.Fields != nil {
+			if f.Slice {
+				b.WriteString("[]")
+			}
+			writeStruct(b, f.Fields)
+		} else {
+			b.WriteString(f.Type)
+		}
+		var tags []string
+		if f.JSON != "" {
+			tags = append(tags, fmt.Sprintf("json:%q", f.JSON))
+		}
+		if f.Query != "" {
+			tags = append(tags, fmt.Sprintf("url:%q", f.Query))
+		}
+		if len(tags) > 0 {
+			fmt.Fprintf(b, " `%s`", strings.Join(tags, " "))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+}
+
+func usesTime(e apispec.Endpoint) bool {
+	var walk func([]apispec.Field) bool
+	walk = func(fields []apispec.Field) bool {
+		for _, f := range fields {
+			if strings.Contains(f.Type, "time.") || walk(f.Fields) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, s := range e.Types {
+		if walk(s.Fields) {
+			return true
+		}
+	}
+	return walk(e.Request) || walk(e.Response)
+}
+
+func methodName(method string) string {
+	return strings.ToUpper(method[:1]) + strings.ToLower(method[1:])
+}
+
+func generateEnums(enums []apispec.Enum) []byte {
+	var b bytes.Buffer
+	b.WriteString(header + "package linepay\n")
+	for _, e := range enums {
+		fmt.Fprintf(&b, "\n// %s type\n// %s is the %s.\ntype %s string\n\n", e.Name, e.Name, e.Doc, e.Name)
+		fmt.Fprintf(&b, "// %s constants\nconst (\n", e.Name)
+		for _, v := range e.Values {
+			fmt.Fprintf(&b, "%s %s = %q\n", v.Name, e.Name, v.Value)
+		}
+		b.WriteString(")\n")
+	}
+	return b.Bytes()
+}
+
+// generateTests writes a test that calls every endpoint and checks the method, path and decoding.
+func generateTests(endpoints []apispec.Endpoint) []byte {
+	var b bytes.Buffer
+	b.WriteString(header + `package linepay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestEndpoints(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		call   func(ctx context.Context, c *Client) (string, error)
+	}{
+`)
+	for _, e := range endpoints {
+		path := e.Path
+		var args []string
+		for _, p := range e.Params {
+			value, arg := "1", "1"
+			if p.Type == "string" {
+				value, arg = "rk", `"rk"`
+			}
+			path = strings.Replace(path, "{"+p.Placeholder+"}", value, 1)
+			args = append(args, arg)
+		}
+		args = append(args, "&"+e.Name+"Request{}")
+		fmt.Fprintf(&b, `{%q, http.Method%s, %q, func(ctx context.Context, c *Client) (string, error) {
+			resp, _, err := c.%s(ctx, %s)
+			if err != nil {
+				return "", err
+			}
+			return resp.ReturnCode, nil
+		}},
+`, e.Name, methodName(e.Method), path, e.Name, strings.Join(args, ", "))
+	}
+	b.WriteString(`	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, _, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc(tt.path, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.method {
+					t.Errorf("method %s; want %s", r.Method, tt.method)
+				}
+				fmt.Fprint(w, ` + "`" + `{"returnCode":"0000","returnMessage":"Success."}` + "`" + `)
+			})
+			code, err := tt.call(context.Background(), client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != ReturnCodeSuccess {
+				t.Errorf("returnCode %q; want %q", code, ReturnCodeSuccess)
+			}
+		})
+	}
+}
+`)
+
+	b.WriteString("\n// apiTypes are the request and response types described in openapi.json.\nvar apiTypes = []interface{}{\n")
+	for _, e := range endpoints {
+		for _, t := range e.Types {
+			fmt.Fprintf(&b, "%s{},\n", t.Name)
+		}
+		fmt.Fprintf(&b, "%sRequest{},\n%sResponse{},\n", e.Name, e.Name)
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
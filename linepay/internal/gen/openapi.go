@@ -0,0 +1,174 @@
+package gen
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay/internal/apispec"
+)
+
+type object = map[string]interface{}
+
+// OpenAPI returns the OpenAPI 3 document of the endpoints in apispec.
+func OpenAPI() ([]byte, error) {
+	schemas := object{}
+	for _, e := range apispec.Enums {
+		var values []string
+		for _, v := range e.Values {
+			values = append(values, v.Value)
+		}
+		schemas[e.Name] = object{"type": "string", "description": e.Doc, "enum": values}
+	}
+
+	paths := object{}
+	for _, e := range apispec.Endpoints {
+		for _, s := range e.Types {
+			schemas[s.Name] = objectSchema(s.Fields, true)
+		}
+		schemas[e.Name+"Request"] = objectSchema(e.Request, true)
+		schemas[e.Name+"Response"] = objectSchema(e.Response, false)
+
+		var params []interface{}
+		for _, p := range e.Params {
+			params = append(params, object{
+				"name": p.Placeholder, "in": "path", "required": true, "schema": typeSchema(p.Type),
+			})
+		}
+		op := object{
+			"operationId": strings.ToLower(e.Name[:1]) + e.Name[1:],
+			"summary":     e.Name,
+			"description": strings.Join(e.Doc, "\n"),
+			"responses": object{
+				"200": object{
+					"description": "returnCode and returnMessage tell whether the call succeeded.",
+					"content":     jsonContent(e.Name + "Response"),
+				},
+			},
+		}
+		if e.Method == "GET" {
+			for _, f := range e.Request {
+				name, _ := tagName(f.Query)
+				param := object{"name": name, "in": "query", "schema": fieldSchema(f)}
+				if strings.HasPrefix(f.Type, "[]") {
+					param["style"] = "form"
+					param["explode"] = true
+				}
+				params = append(params, param)
+			}
+		} else {
+			op["requestBody"] = object{"required": true, "content": jsonContent(e.Name + "Request")}
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+		item, _ := paths[e.Path].(object)
+		if item == nil {
+			item = object{}
+			paths[e.Path] = item
+		}
+		item[strings.ToLower(e.Method)] = op
+	}
+
+	doc := object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "LINE Pay API",
+			"version":     "v3",
+			"description": "The LINE Pay API as sent and received by github.com/gotokatsuya/line-pay-sdk-go. Generated from linepay/internal/apispec; do not edit.",
+		},
+		"servers": []interface{}{
+			object{"url": "https://api-pay.line.me"},
+			object{"url": "https://sandbox-api-pay.line.me"},
+		},
+		"security": []interface{}{
+			object{"channelId": []string{}, "nonce": []string{}, "signature": []string{}},
+		},
+		"paths": paths,
+		"components": object{
+			"schemas": schemas,
+			"securitySchemes": object{
+				"channelId": object{"type": "apiKey", "in": "header", "name": "X-LINE-ChannelId"},
+				"nonce":     object{"type": "apiKey", "in": "header", "name": "X-LINE-Authorization-Nonce"},
+				"signature": object{
+					"type": "apiKey", "in": "header", "name": "X-LINE-Authorization",
+					"description": "Base64 HMAC-SHA256 of channelSecret + path + (body or query string) + nonce, keyed by channelSecret.",
+				},
+			},
+		},
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func jsonContent(schema string) object {
+	return object{"application/json": object{"schema": ref(schema)}}
+}
+
+func ref(name string) object {
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+// objectSchema describes fields; with required, fields without omitempty are required.
+func objectSchema(fields []apispec.Field, required bool) object {
+	properties := object{}
+	var names []string
+	for _, f := range fields {
+		tag := f.JSON
+		if tag == "" {
+			tag = f.Query
+		}
+		name, omitempty := tagName(tag)
+		properties[name] = fieldSchema(f)
+		if required && !omitempty {
+			names = append(names, name)
+		}
+	}
+	schema := object{"type": "object", "properties": properties}
+	if names != nil {
+		schema["required"] = names
+	}
+	return schema
+}
+
+func fieldSchema(f apispec.Field) object {
+	if f.Fields != nil {
+		schema := objectSchema(f.Fields, false)
+		if f.Slice {
+			return object{"type": "array", "items": schema}
+		}
+		return schema
+	}
+	return typeSchema(f.Type)
+}
+
+func typeSchema(t string) object {
+	t = strings.TrimPrefix(t, "*")
+	switch {
+	case strings.HasPrefix(t, "[]"):
+		return object{"type": "array", "items": typeSchema(t[2:])}
+	case t == "string":
+		return object{"type": "string"}
+	case t == "int":
+		return object{"type": "integer"}
+	case t == "int64":
+		return object{"type": "integer", "format": "int64"}
+	case t == "bool":
+		return object{"type": "boolean"}
+	case t == "time.Time":
+		return object{"type": "string", "format": "date-time"}
+	}
+	return ref(t)
+}
+
+func tagName(tag string) (name string, omitempty bool) {
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return parts[0], omitempty
+}
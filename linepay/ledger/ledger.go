@@ -0,0 +1,320 @@
+// Package ledger tracks how much of a LINE Pay transaction has been refunded
+// and refuses refunds that exceed the remaining balance before calling the API.
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+// Errors
+var (
+	ErrInvalidAmount      = errors.New("ledger: refund amount must be positive")
+	ErrExceedsRefundable  = errors.New("ledger: refund amount exceeds refundable balance")
+	ErrNothingToRefund    = errors.New("ledger: nothing left to refund")
+	ErrTransactionUnknown = errors.New("ledger: transaction not found")
+)
+
+// Balance type
+// Pending is the amount of refunds whose outcome is unknown; it is held back
+// from the refundable balance until the refunds are resolved.
+type Balance struct {
+	TransactionID int64            `json:"transactionId"`
+	Currency      linepay.Currency `json:"currency"`
+	Paid          int              `json:"paid"`
+	Refunded      int              `json:"refunded"`
+	Pending       int              `json:"pending,omitempty"`
+}
+
+// Refundable method
+func (b *Balance) Refundable() int {
+	return b.Paid - b.Refunded - b.Pending
+}
+
+// Validate method
+// Validate checks that amount can be refunded from b.
+func (b *Balance) Validate(amount int) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if amount > b.Refundable() {
+		return fmt.Errorf("%w: requested %d, refundable %d", ErrExceedsRefundable, amount, b.Refundable())
+	}
+	return nil
+}
+
+// Source type
+// Source returns the current balance of a transaction.
+type Source interface {
+	Balance(ctx context.Context, transactionID int64) (*Balance, error)
+}
+
+// Entry type
+// Entry is one refund attempt in the audit trail.
+// Unknown is set when the refund got no answer or an ambiguous returnCode, so
+// it may have gone through. Resolved entries are written by Resolve: one per
+// refund it found in PaymentDetails, or a single empty one if there was none.
+// They settle every unknown entry before them.
+type Entry struct {
+	TransactionID       int64     `json:"transactionId"`
+	RefundTransactionID int64     `json:"refundTransactionId,omitempty"`
+	Amount              int       `json:"amount"`
+	Refundable          int       `json:"refundable"`
+	ReturnCode          string    `json:"returnCode,omitempty"`
+	Error               string    `json:"error,omitempty"`
+	Unknown             bool      `json:"unknown,omitempty"`
+	Resolved            bool      `json:"resolved,omitempty"`
+	At                  time.Time `json:"at"`
+}
+
+// Succeeded method
+func (e *Entry) Succeeded() bool {
+	return e.Error == "" && e.ReturnCode == linepay.ReturnCodeSuccess
+}
+
+// pending returns the amount of the unknown entries that no later entry resolved.
+func pending(entries []*Entry) int {
+	total := 0
+	for _, e := range entries {
+		switch {
+		case e.Resolved:
+			total = 0
+		case e.Unknown:
+			total += e.Amount
+		}
+	}
+	return total
+}
+
+// AuditLog type
+type AuditLog interface {
+	Append(ctx context.Context, e *Entry) error
+	List(ctx context.Context, transactionID int64) ([]*Entry, error)
+}
+
+// Refunder type
+type Refunder struct {
+	client *linepay.Client
+	source Source
+	audit  AuditLog
+	now    func() time.Time
+}
+
+// Option type
+type Option func(*Refunder) error
+
+// New returns a new refunder instance. Balances are read from PaymentDetails unless WithSource is given.
+func New(client *linepay.Client, options ...Option) (*Refunder, error) {
+	if client == nil {
+		return nil, errors.New("missing client")
+	}
+	r := &Refunder{
+		client: client,
+		source: &DetailsSource{client: client},
+		audit:  NewMemoryLedger(),
+		now:    time.Now,
+	}
+	for _, option := range options {
+		err := option(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// WithSource function
+func WithSource(s Source) Option {
+	return func(r *Refunder) error {
+		r.source = s
+		return nil
+	}
+}
+
+// WithAuditLog function
+// WithAuditLog sets where refund attempts are recorded. Defaults to an in-memory log.
+func WithAuditLog(l AuditLog) Option {
+	return func(r *Refunder) error {
+		r.audit = l
+		return nil
+	}
+}
+
+// WithClock function
+func WithClock(now func() time.Time) Option {
+	return func(r *Refunder) error {
+		r.now = now
+		return nil
+	}
+}
+
+// Balance method
+// Balance returns the balance of the source, with the refunds of the audit log
+// whose outcome is unknown as Pending.
+func (r *Refunder) Balance(ctx context.Context, transactionID int64) (*Balance, error) {
+	b, err := r.source.Balance(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := r.audit.List(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	b.Pending = pending(entries)
+	return b, nil
+}
+
+// Resolve method
+// Resolve looks up the refunds of the transaction in PaymentDetails and records
+// those the audit log does not know of, which settles the refunds whose outcome
+// was unknown. It does nothing if there are none.
+func (r *Refunder) Resolve(ctx context.Context, transactionID int64) error {
+	entries, err := r.audit.List(ctx, transactionID)
+	if err != nil {
+		return err
+	}
+	if pending(entries) == 0 {
+		return nil
+	}
+	resp, _, err := r.client.PaymentDetails(ctx, &linepay.PaymentDetailsRequest{
+		TransactionID: []int64{transactionID},
+	})
+	if err != nil {
+		return err
+	}
+	if err := linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage); err != nil {
+		return err
+	}
+	known := make(map[int64]bool)
+	for _, e := range entries {
+		if e.RefundTransactionID != 0 {
+			known[e.RefundTransactionID] = true
+		}
+	}
+	var found []*Entry
+	for _, info := range resp.Info {
+		if info.TransactionID != transactionID {
+			continue
+		}
+		for _, refund := range info.RefundList {
+			if known[refund.RefundTransactionID] {
+				continue
+			}
+			found = append(found, &Entry{
+				TransactionID:       transactionID,
+				RefundTransactionID: refund.RefundTransactionID,
+				Amount:              linepay.RefundedAmount(refund.RefundAmount),
+				ReturnCode:          linepay.ReturnCodeSuccess,
+				Resolved:            true,
+			})
+		}
+	}
+	if len(found) == 0 {
+		found = append(found, &Entry{TransactionID: transactionID, Resolved: true})
+	}
+	for _, e := range found {
+		if err := r.record(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefundAll method
+// RefundAll refunds whatever is left of the transaction.
+func (r *Refunder) RefundAll(ctx context.Context, transactionID int64) (*linepay.RefundResponse, error) {
+	b, err := r.Balance(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Refundable() <= 0 {
+		r.record(ctx, &Entry{TransactionID: transactionID, Refundable: b.Refundable(), Error: ErrNothingToRefund.Error()})
+		return nil, ErrNothingToRefund
+	}
+	return r.refund(ctx, b, b.Refundable())
+}
+
+// RefundPartial method
+// RefundPartial refunds amount after checking it against the refundable balance.
+func (r *Refunder) RefundPartial(ctx context.Context, transactionID int64, amount int) (*linepay.RefundResponse, error) {
+	b, err := r.Balance(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Validate(amount); err != nil {
+		r.record(ctx, &Entry{TransactionID: transactionID, Amount: amount, Refundable: b.Refundable(), Error: err.Error()})
+		return nil, err
+	}
+	return r.refund(ctx, b, amount)
+}
+
+func (r *Refunder) refund(ctx context.Context, b *Balance, amount int) (*linepay.RefundResponse, error) {
+	req := &linepay.RefundRequest{}
+	if b.Refunded > 0 || b.Pending > 0 || amount != b.Paid {
+		req.RefundAmount = amount
+	}
+	e := &Entry{TransactionID: b.TransactionID, Amount: amount, Refundable: b.Refundable()}
+	resp, _, err := r.client.Refund(ctx, b.TransactionID, req)
+	if err == nil {
+		e.ReturnCode = resp.ReturnCode
+		e.RefundTransactionID = resp.Info.RefundTransactionID
+		err = linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage)
+		e.Unknown = linepay.AmbiguousReturnCode(resp.ReturnCode)
+	} else {
+		e.Unknown = true
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if auditErr := r.record(ctx, e); auditErr != nil && err == nil {
+		return resp, auditErr
+	}
+	return resp, err
+}
+
+func (r *Refunder) record(ctx context.Context, e *Entry) error {
+	e.At = r.now()
+	return r.audit.Append(ctx, e)
+}
+
+// DetailsSource type
+// DetailsSource computes balances from the PaymentDetails API.
+type DetailsSource struct {
+	client *linepay.Client
+}
+
+// NewDetailsSource returns a new PaymentDetails based source.
+func NewDetailsSource(client *linepay.Client) *DetailsSource {
+	return &DetailsSource{client: client}
+}
+
+// Balance method
+func (s *DetailsSource) Balance(ctx context.Context, transactionID int64) (*Balance, error) {
+	resp, _, err := s.client.PaymentDetails(ctx, &linepay.PaymentDetailsRequest{
+		TransactionID: []int64{transactionID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage); err != nil {
+		return nil, err
+	}
+	for _, info := range resp.Info {
+		if info.TransactionID != transactionID {
+			continue
+		}
+		b := &Balance{TransactionID: transactionID, Currency: info.Currency}
+		for _, p := range info.PayInfo {
+			b.Paid += p.Amount
+		}
+		for _, refund := range info.RefundList {
+			b.Refunded += linepay.RefundedAmount(refund.RefundAmount)
+		}
+		return b, nil
+	}
+	return nil, ErrTransactionUnknown
+}
@@ -0,0 +1,174 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+func setup(t *testing.T, options ...Option) (*Refunder, *http.ServeMux, func()) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client, err := linepay.New("testid", "testsecret", linepay.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(client, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, mux, server.Close
+}
+
+func TestRefunder_PaymentDetails(t *testing.T) {
+	audit := NewMemoryLedger()
+	refunder, mux, teardown := setup(t, WithAuditLog(audit))
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000","info":[{"transactionId":1,"currency":"JPY","payInfo":[{"method":"BALANCE","amount":100}],"refundList":[{"refundTransactionId":2,"refundAmount":-40}]}]}`)
+	})
+	var refundAmount int
+	mux.HandleFunc("/v3/payments/1/refund", func(w http.ResponseWriter, r *http.Request) {
+		v := new(linepay.RefundRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		refundAmount = v.RefundAmount
+		fmt.Fprint(w, `{"returnCode":"0000","info":{"refundTransactionId":3}}`)
+	})
+
+	ctx := context.Background()
+	b, err := refunder.Balance(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Refundable() != 60 {
+		t.Errorf("Refundable %d; want 60", b.Refundable())
+	}
+
+	if _, err := refunder.RefundPartial(ctx, 1, 61); !errors.Is(err, ErrExceedsRefundable) {
+		t.Fatalf("RefundPartial returned %v; want %v", err, ErrExceedsRefundable)
+	}
+	if _, err := refunder.RefundAll(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if refundAmount != 60 {
+		t.Errorf("refundAmount %d; want 60", refundAmount)
+	}
+
+	entries, _ := audit.List(ctx, 1)
+	if len(entries) != 2 || entries[0].Succeeded() || !entries[1].Succeeded() || entries[1].RefundTransactionID != 3 {
+		t.Errorf("audit entries %+v", entries)
+	}
+}
+
+func TestRefunder_LocalLedger(t *testing.T) {
+	ledger := NewMemoryLedger()
+	refunder, mux, teardown := setup(t, WithSource(ledger), WithAuditLog(ledger))
+	defer teardown()
+
+	var refundAmounts []int
+	mux.HandleFunc("/v3/payments/1/refund", func(w http.ResponseWriter, r *http.Request) {
+		v := new(linepay.RefundRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		refundAmounts = append(refundAmounts, v.RefundAmount)
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	ctx := context.Background()
+	ledger.RecordPayment(ctx, 1, 100, "JPY")
+	if _, err := refunder.RefundPartial(ctx, 1, 30); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := refunder.RefundAll(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := refunder.RefundAll(ctx, 1); err != ErrNothingToRefund {
+		t.Errorf("RefundAll returned %v; want %v", err, ErrNothingToRefund)
+	}
+	if want := "[30 70]"; fmt.Sprint(refundAmounts) != want {
+		t.Errorf("refund amounts %v; want %s", refundAmounts, want)
+	}
+}
+
+func TestRefunder_FullRefundOmitsAmount(t *testing.T) {
+	ledger := NewMemoryLedger()
+	refunder, mux, teardown := setup(t, WithSource(ledger), WithAuditLog(ledger))
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/1/refund", func(w http.ResponseWriter, r *http.Request) {
+		v := new(linepay.RefundRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if v.RefundAmount != 0 {
+			t.Errorf("refundAmount %d; want omitted", v.RefundAmount)
+		}
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	ctx := context.Background()
+	ledger.RecordPayment(ctx, 1, 100, "JPY")
+	if _, err := refunder.RefundAll(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefunder_UnknownRefundIsPending(t *testing.T) {
+	ledger := NewMemoryLedger()
+	refunder, mux, teardown := setup(t, WithSource(ledger), WithAuditLog(ledger))
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/1/refund", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+	refundList := `[{"refundTransactionId":5,"refundAmount":-30}]`
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"returnCode":"0000","info":[{"transactionId":1,"refundList":%s}]}`, refundList)
+	})
+
+	ctx := context.Background()
+	ledger.RecordPayment(ctx, 1, 100, "JPY")
+	if _, err := refunder.RefundPartial(ctx, 1, 30); err == nil {
+		t.Fatal("RefundPartial succeeded without an answer")
+	}
+	b, err := refunder.Balance(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Pending != 30 || b.Refundable() != 70 {
+		t.Errorf("Pending %d Refundable %d; want 30 70", b.Pending, b.Refundable())
+	}
+	if _, err := refunder.RefundPartial(ctx, 1, 71); !errors.Is(err, ErrExceedsRefundable) {
+		t.Errorf("RefundPartial returned %v; want %v", err, ErrExceedsRefundable)
+	}
+
+	if err := refunder.Resolve(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	b, err = refunder.Balance(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Pending != 0 || b.Refunded != 30 || b.Refundable() != 70 {
+		t.Errorf("Pending %d Refunded %d Refundable %d; want 0 30 70", b.Pending, b.Refunded, b.Refundable())
+	}
+
+	// a refund that did not go through is released
+	if _, err := refunder.RefundPartial(ctx, 1, 20); err == nil {
+		t.Fatal("RefundPartial succeeded without an answer")
+	}
+	if err := refunder.Resolve(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	b, err = refunder.Balance(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Pending != 0 || b.Refundable() != 70 {
+		t.Errorf("Pending %d Refundable %d; want 0 70", b.Pending, b.Refundable())
+	}
+}
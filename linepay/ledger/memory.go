@@ -0,0 +1,80 @@
+package ledger
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+// MemoryLedger type
+// MemoryLedger is an in-memory AuditLog. Once payments are recorded with
+// RecordPayment it is also a Source that derives balances from the successful
+// and unknown refunds in the log instead of calling PaymentDetails.
+type MemoryLedger struct {
+	mu       sync.Mutex
+	payments map[int64]Balance
+	entries  map[int64][]Entry
+}
+
+var (
+	_ AuditLog = (*MemoryLedger)(nil)
+	_ Source   = (*MemoryLedger)(nil)
+)
+
+// NewMemoryLedger returns a new in-memory ledger.
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{
+		payments: make(map[int64]Balance),
+		entries:  make(map[int64][]Entry),
+	}
+}
+
+// RecordPayment method
+// RecordPayment records the captured amount of a transaction.
+func (l *MemoryLedger) RecordPayment(ctx context.Context, transactionID int64, amount int, currency linepay.Currency) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.payments[transactionID] = Balance{TransactionID: transactionID, Currency: currency, Paid: amount}
+	return nil
+}
+
+// Append method
+func (l *MemoryLedger) Append(ctx context.Context, e *Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[e.TransactionID] = append(l.entries[e.TransactionID], *e)
+	return nil
+}
+
+// List method
+func (l *MemoryLedger) List(ctx context.Context, transactionID int64) ([]*Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := make([]*Entry, 0, len(l.entries[transactionID]))
+	for _, e := range l.entries[transactionID] {
+		e := e
+		list = append(list, &e)
+	}
+	return list, nil
+}
+
+// Balance method
+func (l *MemoryLedger) Balance(ctx context.Context, transactionID int64) (*Balance, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.payments[transactionID]
+	if !ok {
+		return nil, ErrTransactionUnknown
+	}
+	entries := make([]*Entry, 0, len(l.entries[transactionID]))
+	for _, e := range l.entries[transactionID] {
+		e := e
+		if e.Succeeded() {
+			b.Refunded += e.Amount
+		}
+		entries = append(entries, &e)
+	}
+	b.Pending = pending(entries)
+	return &b, nil
+}
@@ -0,0 +1,107 @@
+package linepay
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOpenAPI checks the request and response types against the schemas of openapi.json.
+func TestOpenAPI(t *testing.T) {
+	b, err := ioutil.ReadFile("openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range apiTypes {
+		typ := reflect.TypeOf(v)
+		schema, ok := doc.Components.Schemas[typ.Name()]
+		if !ok {
+			t.Errorf("openapi.json has no schema %s", typ.Name())
+			continue
+		}
+		checkStruct(t, doc.Components.Schemas, typ, schema, typ.Name())
+	}
+}
+
+func checkStruct(t *testing.T, schemas map[string]map[string]interface{}, typ reflect.Type, schema map[string]interface{}, path string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	seen := map[string]bool{}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "" {
+			tag = f.Tag.Get("url")
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "-" {
+			continue
+		}
+		seen[name] = true
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s.%s is missing from openapi.json", path, name)
+			continue
+		}
+		checkType(t, schemas, f.Type, property, path+"."+name)
+	}
+	for name := range properties {
+		if !seen[name] {
+			t.Errorf("%s.%s of openapi.json is not in the Go type", path, name)
+		}
+	}
+}
+
+func checkType(t *testing.T, schemas map[string]map[string]interface{}, typ reflect.Type, schema map[string]interface{}, path string) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	want := map[string]interface{}{}
+	switch {
+	case typ == reflect.TypeOf(time.Time{}):
+		want = map[string]interface{}{"type": "string", "format": "date-time"}
+	case typ.Name() != "" && typ.PkgPath() != "" && typ.Kind() != reflect.Slice:
+		want["$ref"] = "#/components/schemas/" + typ.Name()
+		if _, ok := schemas[typ.Name()]; !ok {
+			t.Errorf("%s: openapi.json has no schema %s", path, typ.Name())
+		}
+	case typ.Kind() == reflect.Struct:
+		if schema["type"] != "object" {
+			t.Errorf("%s is %v in openapi.json; want object", path, schema["type"])
+		}
+		checkStruct(t, schemas, typ, schema, path)
+		return
+	case typ.Kind() == reflect.Slice:
+		items, _ := schema["items"].(map[string]interface{})
+		if schema["type"] != "array" || items == nil {
+			t.Errorf("%s is %v in openapi.json; want array", path, schema["type"])
+			return
+		}
+		checkType(t, schemas, typ.Elem(), items, path+"[]")
+		return
+	case typ.Kind() == reflect.String:
+		want["type"] = "string"
+	case typ.Kind() == reflect.Int:
+		want["type"] = "integer"
+	case typ.Kind() == reflect.Int64:
+		want = map[string]interface{}{"type": "integer", "format": "int64"}
+	case typ.Kind() == reflect.Bool:
+		want["type"] = "boolean"
+	default:
+		t.Errorf("%s: unexpected Go type %s", path, typ)
+		return
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("%s is %v in openapi.json; want %v for %s", path, schema, want, typ)
+	}
+}
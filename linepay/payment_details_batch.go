@@ -0,0 +1,158 @@
+package linepay
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LookupStatus type
+// LookupStatus is what PaymentDetailsBatch found out about one id.
+type LookupStatus string
+
+// LookupStatus constants
+const (
+	LookupFound    LookupStatus = "FOUND"
+	LookupNotFound LookupStatus = "NOT_FOUND"
+	// LookupFailed means the request of the id failed or LINE Pay answered it with an error.
+	LookupFailed LookupStatus = "FAILED"
+)
+
+// PaymentDetailsBatchOptions type
+type PaymentDetailsBatchOptions struct {
+	// Concurrency is the number of requests in flight. Defaults to 1.
+	Concurrency int
+	// Interval is the minimum time between two requests, on top of the rate limit of the client. Zero means no limit.
+	Interval time.Duration
+	// CallOptions apply to every request.
+	CallOptions []CallOption
+}
+
+// PaymentDetailsLookup type
+// PaymentDetailsLookup is the result of one transaction id or order id; only one of them is set.
+type PaymentDetailsLookup struct {
+	TransactionID int64
+	OrderID       string
+	Status        LookupStatus
+	// ReturnCode and ReturnMessage are those of the request of the id.
+	ReturnCode    string
+	ReturnMessage string
+	// Err is the error of the request of the id when it got no answer.
+	Err error
+}
+
+// PaymentDetailsBatchResult type
+type PaymentDetailsBatchResult struct {
+	// Response merges the Info entries of every request LINE Pay answered with
+	// success or ReturnCodeTransactionNotFound. It is nil if there is none.
+	Response *PaymentDetailsResponse
+	// Lookups has one entry per transaction id and then one per order id, in the order of the request.
+	Lookups []PaymentDetailsLookup
+}
+
+// PaymentDetailsBatch method
+// PaymentDetailsBatch looks up any number of transaction ids and order ids in
+// requests of at most MaxPaymentDetailsIDs ids, sent by opts.Concurrency
+// goroutines. Transaction ids and order ids are never sent in the same request.
+// A failed request marks its ids LookupFailed without failing the batch; the
+// error is only non-nil when ctx ends before every request was sent, in which
+// case the ids not sent are LookupFailed with the error of ctx.
+func (c *Client) PaymentDetailsBatch(ctx context.Context, ids *PaymentDetailsRequest, opts PaymentDetailsBatchOptions) (*PaymentDetailsBatchResult, error) {
+	if ids == nil {
+		return new(PaymentDetailsBatchResult), nil
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var tick <-chan time.Time
+	if opts.Interval > 0 {
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	chunks := chunkPaymentDetailsRequest(ids)
+	resps := make([]*PaymentDetailsResponse, len(chunks))
+	errs := make([]error, len(chunks))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				resps[i], _, errs[i] = c.paymentDetails(ctx, chunks[i], opts.CallOptions...)
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for i := range chunks {
+		if tick != nil && i > 0 {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+				break feed
+			case <-tick:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := new(PaymentDetailsBatchResult)
+	seen := make(map[int64]bool)
+	for i, chunk := range chunks {
+		resp := resps[i]
+		if resp == nil && errs[i] == nil {
+			errs[i] = err
+		}
+		if resp != nil && (resp.ReturnCode == ReturnCodeSuccess || resp.ReturnCode == ReturnCodeTransactionNotFound) {
+			result.Response = mergePaymentDetails(result.Response, resp, seen)
+		}
+		for _, id := range chunk.TransactionID {
+			l := lookup(resp, errs[i], func(info int) bool { return resp.Info[info].TransactionID == id })
+			l.TransactionID = id
+			result.Lookups = append(result.Lookups, l)
+		}
+		for _, id := range chunk.OrderID {
+			l := lookup(resp, errs[i], func(info int) bool { return resp.Info[info].OrderID == id })
+			l.OrderID = id
+			result.Lookups = append(result.Lookups, l)
+		}
+	}
+	return result, err
+}
+
+// lookup returns the result of one id of the request answered by resp or failed with err.
+// match reports whether resp.Info[i] belongs to the id.
+func lookup(resp *PaymentDetailsResponse, err error, match func(i int) bool) PaymentDetailsLookup {
+	if resp == nil {
+		return PaymentDetailsLookup{Status: LookupFailed, Err: err}
+	}
+	l := PaymentDetailsLookup{ReturnCode: resp.ReturnCode, ReturnMessage: resp.ReturnMessage}
+	switch resp.ReturnCode {
+	case ReturnCodeSuccess:
+		l.Status = LookupNotFound
+		for i := range resp.Info {
+			if match(i) {
+				l.Status = LookupFound
+				break
+			}
+		}
+	case ReturnCodeTransactionNotFound:
+		l.Status = LookupNotFound
+	default:
+		l.Status = LookupFailed
+	}
+	return l
+}
@@ -0,0 +1,92 @@
+package linepay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// MaxPaymentDetailsIDs is the number of transaction ids or order ids LINE Pay accepts in one PaymentDetails request.
+const MaxPaymentDetailsIDs = 100
+
+// ErrMixedPaymentDetailsIDs is returned by PaymentDetails for a request with both
+// transaction ids and order ids that has to be split, as the split requests
+// would not mean the same as the single one. Use PaymentDetailsBatch instead.
+var ErrMixedPaymentDetailsIDs = errors.New("linepay: cannot split a PaymentDetails request with both transaction ids and order ids")
+
+// PaymentDetails method
+// LINE Payの取引履歴を照会するAPIです。オーソリと売上確定の取引を照会できます。
+// "fields"を設定することで、取引情報または注文情報を選択的に照会することができます。
+// More than MaxPaymentDetailsIDs transaction ids or order ids are split into
+// several requests whose Info entries are merged; a request with both kinds of
+// ids fails with ErrMixedPaymentDetailsIDs then. The merged response reports
+// ReturnCodeSuccess if any request found transactions; a returnCode other than
+// ReturnCodeTransactionNotFound is returned as is with the entries found so far.
+// The returned *http.Response is the one of the last request.
+func (c *Client) PaymentDetails(ctx context.Context, req *PaymentDetailsRequest, options ...CallOption) (*PaymentDetailsResponse, *http.Response, error) {
+	if req == nil || (len(req.TransactionID) <= MaxPaymentDetailsIDs && len(req.OrderID) <= MaxPaymentDetailsIDs) {
+		return c.paymentDetails(ctx, req, options...)
+	}
+	if len(req.TransactionID) > 0 && len(req.OrderID) > 0 {
+		return nil, nil, ErrMixedPaymentDetailsIDs
+	}
+
+	var merged *PaymentDetailsResponse
+	var httpResp *http.Response
+	seen := make(map[int64]bool)
+	for _, chunk := range chunkPaymentDetailsRequest(req) {
+		resp, hr, err := c.paymentDetails(ctx, chunk, options...)
+		httpResp = hr
+		if err != nil {
+			return nil, httpResp, err
+		}
+		merged = mergePaymentDetails(merged, resp, seen)
+		if resp.ReturnCode != ReturnCodeSuccess && resp.ReturnCode != ReturnCodeTransactionNotFound {
+			merged.ReturnCode, merged.ReturnMessage = resp.ReturnCode, resp.ReturnMessage
+			return merged, httpResp, nil
+		}
+	}
+	return merged, httpResp, nil
+}
+
+// mergePaymentDetails adds the Info entries of resp not in seen to merged and returns it.
+// merged takes the returnCode of resp if it is nil or only reports ReturnCodeTransactionNotFound.
+func mergePaymentDetails(merged, resp *PaymentDetailsResponse, seen map[int64]bool) *PaymentDetailsResponse {
+	if merged == nil || (merged.ReturnCode == ReturnCodeTransactionNotFound && resp.ReturnCode == ReturnCodeSuccess) {
+		info := merged
+		merged = &PaymentDetailsResponse{ReturnCode: resp.ReturnCode, ReturnMessage: resp.ReturnMessage, Extra: resp.Extra}
+		if info != nil {
+			merged.Info = info.Info
+		}
+	}
+	for _, entry := range resp.Info {
+		if seen[entry.TransactionID] {
+			continue
+		}
+		seen[entry.TransactionID] = true
+		merged.Info = append(merged.Info, entry)
+	}
+	return merged
+}
+
+// chunkPaymentDetailsRequest splits the ids of req into requests of at most MaxPaymentDetailsIDs ids each.
+func chunkPaymentDetailsRequest(req *PaymentDetailsRequest) []*PaymentDetailsRequest {
+	var chunks []*PaymentDetailsRequest
+	for ids := req.TransactionID; len(ids) > 0; {
+		n := len(ids)
+		if n > MaxPaymentDetailsIDs {
+			n = MaxPaymentDetailsIDs
+		}
+		chunks = append(chunks, &PaymentDetailsRequest{TransactionID: ids[:n], Fields: req.Fields})
+		ids = ids[n:]
+	}
+	for ids := req.OrderID; len(ids) > 0; {
+		n := len(ids)
+		if n > MaxPaymentDetailsIDs {
+			n = MaxPaymentDetailsIDs
+		}
+		chunks = append(chunks, &PaymentDetailsRequest{OrderID: ids[:n], Fields: req.Fields})
+		ids = ids[n:]
+	}
+	return chunks
+}
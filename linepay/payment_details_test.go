@@ -0,0 +1,236 @@
+package linepay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_PaymentDetailsChunked(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var calls [][]string
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		ids := r.URL.Query()["transactionId"]
+		calls = append(calls, ids)
+		if r.URL.Query().Get("fields") != "TRANSACTION" {
+			t.Errorf("fields %q; want TRANSACTION", r.URL.Query().Get("fields"))
+		}
+		if len(calls) == 2 {
+			// No transaction of this chunk exists.
+			json.NewEncoder(w).Encode(map[string]string{"returnCode": ReturnCodeTransactionNotFound})
+			return
+		}
+		type info struct {
+			TransactionID int64 `json:"transactionId"`
+		}
+		resp := struct {
+			ReturnCode string `json:"returnCode"`
+			Info       []info `json:"info"`
+		}{ReturnCode: ReturnCodeSuccess}
+		for _, id := range ids {
+			n, _ := strconv.ParseInt(id, 10, 64)
+			resp.Info = append(resp.Info, info{n})
+		}
+		// The first id of every chunk is reported twice.
+		resp.Info = append(resp.Info, resp.Info[0])
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	req := &PaymentDetailsRequest{Fields: "TRANSACTION"}
+	for i := 1; i <= 250; i++ {
+		req.TransactionID = append(req.TransactionID, int64(i))
+	}
+	resp, _, err := client.PaymentDetails(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("calls %d; want 3", len(calls))
+	}
+	for i, want := range []int{100, 100, 50} {
+		if len(calls[i]) != want {
+			t.Errorf("call %d sent %d ids; want %d", i, len(calls[i]), want)
+		}
+		if calls[i][0] != strconv.Itoa(i*100+1) {
+			t.Errorf("call %d starts at %s; want %d", i, calls[i][0], i*100+1)
+		}
+	}
+	if resp.ReturnCode != ReturnCodeSuccess {
+		t.Errorf("returnCode %q; want %q", resp.ReturnCode, ReturnCodeSuccess)
+	}
+	if len(resp.Info) != 150 {
+		t.Fatalf("info %d; want 150", len(resp.Info))
+	}
+	if resp.Info[0].TransactionID != 1 || resp.Info[149].TransactionID != 250 {
+		t.Errorf("info from %d to %d; want 1 to 250", resp.Info[0].TransactionID, resp.Info[149].TransactionID)
+	}
+}
+
+func TestClient_PaymentDetailsChunkedError(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var calls int
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"returnCode":"1104","returnMessage":"merchant not found"}`))
+	})
+	req := &PaymentDetailsRequest{OrderID: make([]string, 101)}
+	resp, _, err := client.PaymentDetails(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("calls %d; want 1", calls)
+	}
+	if resp.ReturnCode != "1104" {
+		t.Errorf("returnCode %q; want 1104", resp.ReturnCode)
+	}
+}
+
+func TestClient_PaymentDetailsChunkedMixed(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var calls int
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	ctx := context.Background()
+	req := &PaymentDetailsRequest{TransactionID: make([]int64, 101), OrderID: []string{"o1"}}
+	if _, _, err := client.PaymentDetails(ctx, req); err != ErrMixedPaymentDetailsIDs {
+		t.Errorf("PaymentDetails returned %v; want %v", err, ErrMixedPaymentDetailsIDs)
+	}
+	if calls != 0 {
+		t.Errorf("calls %d; want 0", calls)
+	}
+
+	// Mixed requests that fit in one call are sent as they are.
+	req = &PaymentDetailsRequest{TransactionID: make([]int64, 100), OrderID: []string{"o1"}}
+	if _, _, err := client.PaymentDetails(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("calls %d; want 1", calls)
+	}
+}
+
+func TestClient_PaymentDetailsBatch(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight, calls int
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		q := r.URL.Query()
+		switch {
+		case q.Get("transactionId") == "201":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case len(q["orderId"]) > 0:
+			fmt.Fprint(w, `{"returnCode":"1150","returnMessage":"Transaction record not found."}`)
+			return
+		}
+		type info struct {
+			TransactionID int64  `json:"transactionId"`
+			OrderID       string `json:"orderId"`
+		}
+		resp := struct {
+			ReturnCode string `json:"returnCode"`
+			Info       []info `json:"info"`
+		}{ReturnCode: ReturnCodeSuccess}
+		for _, id := range q["transactionId"] {
+			// Odd transactions do not exist.
+			if n, _ := strconv.ParseInt(id, 10, 64); n%2 == 0 {
+				resp.Info = append(resp.Info, info{n, "order-" + id})
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	ids := &PaymentDetailsRequest{OrderID: []string{"unknown"}}
+	for i := 1; i <= 250; i++ {
+		ids.TransactionID = append(ids.TransactionID, int64(i))
+	}
+	result, err := client.PaymentDetailsBatch(context.Background(), ids, PaymentDetailsBatchOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 {
+		t.Errorf("calls %d; want 4", calls)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("%d calls in flight; want at most 2", maxInFlight)
+	}
+	if len(result.Response.Info) != 100 {
+		t.Errorf("info %d; want 100", len(result.Response.Info))
+	}
+	if result.Response.ReturnCode != ReturnCodeSuccess {
+		t.Errorf("returnCode %q; want %q", result.Response.ReturnCode, ReturnCodeSuccess)
+	}
+	if len(result.Lookups) != 251 {
+		t.Fatalf("lookups %d; want 251", len(result.Lookups))
+	}
+	for _, tt := range []struct {
+		i      int
+		want   LookupStatus
+		hasErr bool
+	}{
+		{0, LookupNotFound, false},
+		{1, LookupFound, false},
+		{199, LookupFound, false},
+		{200, LookupFailed, true},
+		{249, LookupFailed, true},
+		{250, LookupNotFound, false},
+	} {
+		l := result.Lookups[tt.i]
+		if l.Status != tt.want || (l.Err != nil) != tt.hasErr {
+			t.Errorf("lookup %d = %+v; want %s", tt.i, l, tt.want)
+		}
+	}
+	if l := result.Lookups[250]; l.OrderID != "unknown" || l.ReturnCode != ReturnCodeTransactionNotFound {
+		t.Errorf("order lookup = %+v", l)
+	}
+}
+
+func TestClient_PaymentDetailsBatchCanceled(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		fmt.Fprint(w, `{"returnCode":"1150"}`)
+	})
+	ids := &PaymentDetailsRequest{TransactionID: make([]int64, 300)}
+	result, err := client.PaymentDetailsBatch(ctx, ids, PaymentDetailsBatchOptions{Interval: time.Hour})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err %v; want %v", err, context.Canceled)
+	}
+	if l := result.Lookups[299]; l.Status != LookupFailed || !errors.Is(l.Err, context.Canceled) {
+		t.Errorf("lookup of unsent id = %+v", l)
+	}
+}
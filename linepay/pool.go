@@ -0,0 +1,209 @@
+package linepay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrNoMerchant is returned by ClientPool when the context names no merchant.
+var ErrNoMerchant = errors.New("linepay: no merchant in context")
+
+// CredentialsLoader type
+// CredentialsLoader returns the channel credentials of a merchant, such as a shop or a store branch.
+type CredentialsLoader interface {
+	LoadCredentials(ctx context.Context, merchantID string) (*Credentials, error)
+}
+
+// StaticCredentials type
+// StaticCredentials is a CredentialsLoader backed by a map of merchant id to credentials.
+type StaticCredentials map[string]Credentials
+
+// LoadCredentials method
+func (s StaticCredentials) LoadCredentials(ctx context.Context, merchantID string) (*Credentials, error) {
+	c, ok := s[merchantID]
+	if !ok {
+		return nil, errors.New("linepay: unknown merchant " + merchantID)
+	}
+	return &c, nil
+}
+
+type merchantKey struct{}
+
+// WithMerchant function
+// WithMerchant returns a context that routes ClientPool calls to merchantID.
+func WithMerchant(ctx context.Context, merchantID string) context.Context {
+	return context.WithValue(ctx, merchantKey{}, merchantID)
+}
+
+// MerchantFromContext function
+func MerchantFromContext(ctx context.Context) (string, bool) {
+	merchantID, ok := ctx.Value(merchantKey{}).(string)
+	return merchantID, ok && merchantID != ""
+}
+
+// ClientPool type
+// ClientPool holds one Client per merchant. Clients are created on first use
+// with credentials from the CredentialsLoader and share one http.Client.
+type ClientPool struct {
+	loader  CredentialsLoader
+	options []ClientOption
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// NewClientPool returns a new client pool instance. options are applied to every client;
+// unless they include WithHTTPClient, the clients share a new http.Client.
+func NewClientPool(loader CredentialsLoader, options ...ClientOption) (*ClientPool, error) {
+	if loader == nil {
+		return nil, errors.New("missing credentials loader")
+	}
+	shared := &http.Client{Transport: http.DefaultTransport}
+	return &ClientPool{
+		loader:  loader,
+		options: append([]ClientOption{WithHTTPClient(shared)}, options...),
+		clients: make(map[string]*Client),
+	}, nil
+}
+
+// Client method
+// Client returns the client of merchantID, creating it if necessary.
+func (p *ClientPool) Client(ctx context.Context, merchantID string) (*Client, error) {
+	p.mu.Lock()
+	c, ok := p.clients[merchantID]
+	p.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	creds, err := p.loader.LoadCredentials(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	c, err = New(creds.ChannelID, creds.ChannelSecret, p.options...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if existing, ok := p.clients[merchantID]; ok {
+		return existing, nil
+	}
+	p.clients[merchantID] = c
+	return c, nil
+}
+
+// ClientFromContext method
+// ClientFromContext returns the client of the merchant set with WithMerchant.
+func (p *ClientPool) ClientFromContext(ctx context.Context) (*Client, error) {
+	merchantID, ok := MerchantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoMerchant
+	}
+	return p.Client(ctx, merchantID)
+}
+
+// Remove method
+// Remove drops the cached client of merchantID so that its credentials are loaded again on next use.
+func (p *ClientPool) Remove(merchantID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.clients, merchantID)
+}
+
+// Request method
+// Request routes by the merchant in ctx, falling back to options.extras.branchId of req.
+func (p *ClientPool) Request(ctx context.Context, req *RequestRequest, options ...CallOption) (*RequestResponse, *http.Response, error) {
+	if _, ok := MerchantFromContext(ctx); !ok && req.Options != nil && req.Options.Extras != nil && req.Options.Extras.BranchID != "" {
+		ctx = WithMerchant(ctx, req.Options.Extras.BranchID)
+	}
+	c, err := p.ClientFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Request(ctx, req, options...)
+}
+
+// Confirm method
+func (p *ClientPool) Confirm(ctx context.Context, transactionID int64, req *ConfirmRequest, options ...CallOption) (*ConfirmResponse, *http.Response, error) {
+	c, err := p.ClientFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Confirm(ctx, transactionID, req, options...)
+}
+
+// Capture method
+func (p *ClientPool) Capture(ctx context.Context, transactionID int64, req *CaptureRequest, options ...CallOption) (*CaptureResponse, *http.Response, error) {
+	c, err := p.ClientFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Capture(ctx, transactionID, req, options...)
+}
+
+// Void method
+func (p *ClientPool) Void(ctx context.Context, transactionID int64, req *VoidRequest, options ...CallOption) (*VoidResponse, *http.Response, error) {
+	c, err := p.ClientFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Void(ctx, transactionID, req, options...)
+}
+
+// Refund method
+func (p *ClientPool) Refund(ctx context.Context, transactionID int64, req *RefundRequest, options ...CallOption) (*RefundResponse, *http.Response, error) {
+	c, err := p.ClientFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Refund(ctx, transactionID, req, options...)
+}
+
+// PaymentDetails method
+func (p *ClientPool) PaymentDetails(ctx context.Context, req *PaymentDetailsRequest, options ...CallOption) (*PaymentDetailsResponse, *http.Response, error) {
+	c, err := p.ClientFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.PaymentDetails(ctx, req, options...)
+}
+
+// CheckPaymentStatus method
+func (p *ClientPool) CheckPaymentStatus(ctx context.Context, transactionID int64, req *CheckPaymentStatusRequest, options ...CallOption) (*CheckPaymentStatusResponse, *http.Response, error) {
+	c, err := p.ClientFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.CheckPaymentStatus(ctx, transactionID, req, options...)
+}
+
+// PayPreapproved method
+func (p *ClientPool) PayPreapproved(ctx context.Context, regKey string, req *PayPreapprovedRequest, options ...CallOption) (*PayPreapprovedResponse, *http.Response, error) {
+	c, err := p.ClientFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.PayPreapproved(ctx, regKey, req, options...)
+}
+
+// CheckRegKey method
+func (p *ClientPool) CheckRegKey(ctx context.Context, regKey string, req *CheckRegKeyRequest, options ...CallOption) (*CheckRegKeyResponse, *http.Response, error) {
+	c, err := p.ClientFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.CheckRegKey(ctx, regKey, req, options...)
+}
+
+// ExpireRegKey method
+func (p *ClientPool) ExpireRegKey(ctx context.Context, regKey string, req *ExpireRegKeyRequest, options ...CallOption) (*ExpireRegKeyResponse, *http.Response, error) {
+	c, err := p.ClientFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.ExpireRegKey(ctx, regKey, req, options...)
+}
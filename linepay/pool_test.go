@@ -0,0 +1,58 @@
+package linepay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestClientPool(t *testing.T) {
+	_, mux, serverURL, teardown := setup()
+	defer teardown()
+
+	pool, err := NewClientPool(StaticCredentials{
+		"shop-a":   {ChannelID: "id-a", ChannelSecret: "secret-a"},
+		"branch-b": {ChannelID: "id-b", ChannelSecret: "secret-b"},
+	}, WithEndpoint(serverURL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var channelIDs []string
+	mux.HandleFunc("/v3/payments/request", func(w http.ResponseWriter, r *http.Request) {
+		channelIDs = append(channelIDs, r.Header.Get("X-LINE-ChannelId"))
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		channelIDs = append(channelIDs, r.Header.Get("X-LINE-ChannelId"))
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	ctx := context.Background()
+	if _, _, err := pool.Confirm(ctx, 1, &ConfirmRequest{}); err != ErrNoMerchant {
+		t.Fatalf("Confirm without merchant returned %v; want %v", err, ErrNoMerchant)
+	}
+	if _, _, err := pool.Confirm(WithMerchant(ctx, "shop-a"), 1, &ConfirmRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	req := &RequestRequest{Options: &RequestOptions{Extras: &RequestOptionsExtras{BranchID: "branch-b"}}}
+	if _, _, err := pool.Request(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[id-a id-b]"; fmt.Sprint(channelIDs) != want {
+		t.Errorf("channel ids %v; want %s", channelIDs, want)
+	}
+
+	a, _ := pool.Client(ctx, "shop-a")
+	b, _ := pool.Client(ctx, "branch-b")
+	if a.httpClient != b.httpClient {
+		t.Error("clients do not share the http.Client")
+	}
+	if again, _ := pool.Client(ctx, "shop-a"); again != a {
+		t.Error("client was not cached")
+	}
+	if _, err := pool.Client(ctx, "unknown"); err == nil {
+		t.Error("Client returned no error for an unknown merchant")
+	}
+}
@@ -0,0 +1,229 @@
+package linepay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrRateLimitWait is returned without calling LINE Pay when waiting for a
+// rate limit token would outlast the deadline of the call.
+// It matches context.DeadlineExceeded with errors.Is.
+var ErrRateLimitWait error = rateLimitWaitError{}
+
+type rateLimitWaitError struct{}
+
+func (rateLimitWaitError) Error() string {
+	return "linepay: rate limit wait exceeds the deadline"
+}
+
+// Is method
+func (rateLimitWaitError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// OperationClass type
+// OperationClass groups API calls that share a rate limit.
+type OperationClass string
+
+// OperationClass constants
+const (
+	// ClassPayment covers calls that move money or change a transaction (POST).
+	ClassPayment OperationClass = "payment"
+	// ClassInquiry covers calls that only read (GET).
+	ClassInquiry OperationClass = "inquiry"
+)
+
+func classOf(req *http.Request) OperationClass {
+	if req.Method == http.MethodGet {
+		return ClassInquiry
+	}
+	return ClassPayment
+}
+
+// Limit type
+// Limit is a token bucket refilled with Rate tokens per second and holding at most Burst tokens.
+// A zero Rate means unlimited.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimit type
+type RateLimit struct {
+	Payment Limit
+	Inquiry Limit
+	// Adaptive lowers the rate of a class when LINE Pay answers with HTTP 429
+	// or one of ThrottleReturnCodes, and restores it as calls succeed again.
+	Adaptive            bool
+	ThrottleReturnCodes []string
+}
+
+// WithRateLimit function
+// WithRateLimit makes the client wait for a token before every call.
+// Waiting honours the deadline and cancellation of the call's context.
+func WithRateLimit(rl RateLimit) ClientOption {
+	return func(client *Client) error {
+		if rl.Payment.Rate < 0 || rl.Inquiry.Rate < 0 {
+			return errors.New("rate must not be negative")
+		}
+		l := &rateLimiter{
+			buckets:        make(map[OperationClass]*tokenBucket),
+			adaptive:       rl.Adaptive,
+			throttleCodes:  make(map[string]bool),
+			decodeResponse: rl.Adaptive && len(rl.ThrottleReturnCodes) > 0,
+		}
+		for _, code := range rl.ThrottleReturnCodes {
+			l.throttleCodes[code] = true
+		}
+		for class, limit := range map[OperationClass]Limit{ClassPayment: rl.Payment, ClassInquiry: rl.Inquiry} {
+			if limit.Rate > 0 {
+				l.buckets[class] = newTokenBucket(limit, time.Now)
+			}
+		}
+		client.limiter = l
+		return nil
+	}
+}
+
+// rateLimiter type
+type rateLimiter struct {
+	buckets        map[OperationClass]*tokenBucket
+	adaptive       bool
+	throttleCodes  map[string]bool
+	decodeResponse bool
+}
+
+func (l *rateLimiter) wait(ctx context.Context, class OperationClass) error {
+	b, ok := l.buckets[class]
+	if !ok {
+		return nil
+	}
+	return b.wait(ctx)
+}
+
+// observe adapts the rate of class to the outcome of a call.
+func (l *rateLimiter) observe(class OperationClass, resp *http.Response, body []byte) {
+	b, ok := l.buckets[class]
+	if !ok || !l.adaptive {
+		return
+	}
+	throttled := resp.StatusCode == http.StatusTooManyRequests
+	if !throttled && l.decodeResponse && len(body) > 0 {
+		throttled = l.throttleCodes[peekReturnCode(body)]
+	}
+	if throttled {
+		b.slowDown()
+	} else {
+		b.speedUp()
+	}
+}
+
+// peekReturnCode returns the returnCode of a JSON response body, or "" if there is none.
+func peekReturnCode(body []byte) string {
+	var v struct {
+		ReturnCode string `json:"returnCode"`
+	}
+	json.Unmarshal(body, &v)
+	return v.ReturnCode
+}
+
+const (
+	minRateFactor = 1.0 / 16
+	recoveryStep  = 1.25
+)
+
+// tokenBucket type
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	factor float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(limit Limit, now func() time.Time) *tokenBucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   limit.Rate,
+		burst:  burst,
+		factor: 1,
+		tokens: burst,
+		last:   now(),
+		now:    now,
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait before using it.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	rate := b.rate * b.factor
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// cancel returns a token taken by reserve that was not used.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	d := b.reserve()
+	if d == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		b.cancel()
+		return ErrRateLimitWait
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (b *tokenBucket) slowDown() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.factor /= 2
+	if b.factor < minRateFactor {
+		b.factor = minRateFactor
+	}
+	if b.tokens > 0 {
+		b.tokens = 0
+	}
+}
+
+func (b *tokenBucket) speedUp() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.factor *= recoveryStep
+	if b.factor > 1 {
+		b.factor = 1
+	}
+}
@@ -0,0 +1,83 @@
+package linepay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newTokenBucket(Limit{Rate: 10, Burst: 2}, func() time.Time { return now })
+
+	for i := 0; i < 2; i++ {
+		if d := b.reserve(); d != 0 {
+			t.Fatalf("reserve %d waited %v within burst", i, d)
+		}
+	}
+	if d := b.reserve(); d != 100*time.Millisecond {
+		t.Errorf("reserve beyond burst waited %v; want 100ms", d)
+	}
+	b.cancel()
+
+	b.slowDown()
+	if d := b.reserve(); d != 200*time.Millisecond {
+		t.Errorf("reserve after slowDown waited %v; want 200ms", d)
+	}
+	b.cancel()
+
+	for i := 0; i < 4; i++ {
+		b.speedUp()
+	}
+	if b.factor != 1 {
+		t.Errorf("factor %v after recovery; want 1", b.factor)
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	_, mux, serverURL, teardown := setup()
+	defer teardown()
+
+	client, err := New("testid", "testsecret", WithEndpoint(serverURL), WithRateLimit(RateLimit{
+		Payment:             Limit{Rate: 1, Burst: 1},
+		Adaptive:            true,
+		ThrottleReturnCodes: []string{"9999"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"returnCode":"9999"}`)
+	})
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	ctx := context.Background()
+	if _, _, err := client.Confirm(ctx, 1, &ConfirmRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if f := client.limiter.buckets[ClassPayment].factor; f != 0.5 {
+		t.Errorf("factor %v after throttle return code; want 0.5", f)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := client.Confirm(ctx, 1, &ConfirmRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Confirm returned %v; want %v", err, context.DeadlineExceeded)
+	}
+	if calls != 1 {
+		t.Errorf("confirm called %d times; want 1", calls)
+	}
+
+	// inquiries are not limited
+	if _, _, err := client.PaymentDetails(ctx, &PaymentDetailsRequest{TransactionID: []int64{1}}); err != nil {
+		t.Errorf("PaymentDetails returned %v", err)
+	}
+}
@@ -0,0 +1,334 @@
+// Package reconcile compares an order database with what LINE Pay reports through PaymentDetails.
+package reconcile
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay/state"
+)
+
+// MaxBatchSize is the number of ids PaymentDetails accepts in one call.
+const MaxBatchSize = linepay.MaxPaymentDetailsIDs
+
+// Order type
+// Order is the application's view of a payment. TransactionID is used for the
+// lookup when set, OrderID otherwise. An empty State is not checked.
+type Order struct {
+	OrderID        string           `json:"orderId"`
+	TransactionID  int64            `json:"transactionId,omitempty"`
+	Amount         int              `json:"amount"`
+	Currency       linepay.Currency `json:"currency"`
+	State          state.State      `json:"state,omitempty"`
+	RefundedAmount int              `json:"refundedAmount,omitempty"`
+}
+
+// OrderIterator type
+// OrderIterator yields the orders to reconcile. Next returns io.EOF after the last order.
+type OrderIterator interface {
+	Next(ctx context.Context) (*Order, error)
+}
+
+// SliceIterator type
+type SliceIterator struct {
+	orders []*Order
+}
+
+// NewSliceIterator returns an iterator over orders.
+func NewSliceIterator(orders []*Order) *SliceIterator {
+	return &SliceIterator{orders: orders}
+}
+
+// Next method
+func (it *SliceIterator) Next(ctx context.Context) (*Order, error) {
+	if len(it.orders) == 0 {
+		return nil, io.EOF
+	}
+	o := it.orders[0]
+	it.orders = it.orders[1:]
+	return o, nil
+}
+
+// Kind type
+type Kind string
+
+// Kind constants
+const (
+	KindMissingTransaction Kind = "MISSING_TRANSACTION"
+	KindAmountMismatch     Kind = "AMOUNT_MISMATCH"
+	KindCurrencyMismatch   Kind = "CURRENCY_MISMATCH"
+	KindStatusDrift        Kind = "STATUS_DRIFT"
+	KindUnexpectedRefund   Kind = "UNEXPECTED_REFUND"
+	KindMissingRefund      Kind = "MISSING_REFUND"
+	KindLookupFailed       Kind = "LOOKUP_FAILED"
+)
+
+// Mismatch type
+type Mismatch struct {
+	OrderID       string `json:"orderId"`
+	TransactionID int64  `json:"transactionId,omitempty"`
+	Kind          Kind   `json:"kind"`
+	Expected      string `json:"expected,omitempty"`
+	Actual        string `json:"actual,omitempty"`
+}
+
+// Report type
+type Report struct {
+	Checked    int        `json:"checked"`
+	Mismatches []Mismatch `json:"mismatches"`
+}
+
+// OK method
+func (r *Report) OK() bool {
+	return len(r.Mismatches) == 0
+}
+
+// WriteCSV method
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"order_id", "transaction_id", "kind", "expected", "actual"}); err != nil {
+		return err
+	}
+	for _, m := range r.Mismatches {
+		transactionID := ""
+		if m.TransactionID != 0 {
+			transactionID = strconv.FormatInt(m.TransactionID, 10)
+		}
+		if err := cw.Write([]string{m.OrderID, transactionID, string(m.Kind), m.Expected, m.Actual}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Reconciler type
+type Reconciler struct {
+	client    *linepay.Client
+	batchSize int
+}
+
+// Option type
+type Option func(*Reconciler) error
+
+// New returns a new reconciler instance.
+func New(client *linepay.Client, options ...Option) (*Reconciler, error) {
+	if client == nil {
+		return nil, errors.New("missing client")
+	}
+	r := &Reconciler{
+		client:    client,
+		batchSize: MaxBatchSize,
+	}
+	for _, option := range options {
+		err := option(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// WithBatchSize function
+// WithBatchSize sets how many orders are looked up per PaymentDetails call. Defaults to MaxBatchSize.
+func WithBatchSize(n int) Option {
+	return func(r *Reconciler) error {
+		if n <= 0 || n > MaxBatchSize {
+			return errors.New("reconcile: batch size must be between 1 and 100")
+		}
+		r.batchSize = n
+		return nil
+	}
+}
+
+// Run method
+// Run reconciles every order yielded by it. Lookup failures are reported as
+// KindLookupFailed mismatches; the returned error only reports iterator failures and ctx cancellation.
+func (r *Reconciler) Run(ctx context.Context, it OrderIterator) (*Report, error) {
+	report := &Report{}
+	batch := make([]*Order, 0, r.batchSize)
+	for {
+		o, err := it.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+		batch = append(batch, o)
+		if len(batch) == r.batchSize {
+			if err := r.check(ctx, batch, report); err != nil {
+				return report, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := r.check(ctx, batch, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// transaction is the part of a PaymentDetails entry that is reconciled.
+type transaction struct {
+	transactionID int64
+	orderID       string
+	currency      linepay.Currency
+	payStatus     linepay.PayStatus
+	amount        int
+	refunded      int
+}
+
+func (r *Reconciler) check(ctx context.Context, batch []*Order, report *Report) error {
+	byTransactionID := make(map[int64]*transaction)
+	byOrderID := make(map[string]*transaction)
+	failed := make(map[*Order]error)
+
+	var transactionIDs []int64
+	var orderIDs []string
+	for _, o := range batch {
+		if o.TransactionID != 0 {
+			transactionIDs = append(transactionIDs, o.TransactionID)
+		} else {
+			orderIDs = append(orderIDs, o.OrderID)
+		}
+	}
+	lookup := func(req *linepay.PaymentDetailsRequest, byTransaction bool) error {
+		txs, err := r.lookup(ctx, req)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			for _, o := range batch {
+				if (o.TransactionID != 0) == byTransaction {
+					failed[o] = err
+				}
+			}
+			return nil
+		}
+		for _, tx := range txs {
+			byTransactionID[tx.transactionID] = tx
+			byOrderID[tx.orderID] = tx
+		}
+		return nil
+	}
+	if len(transactionIDs) > 0 {
+		if err := lookup(&linepay.PaymentDetailsRequest{TransactionID: transactionIDs}, true); err != nil {
+			return err
+		}
+	}
+	if len(orderIDs) > 0 {
+		if err := lookup(&linepay.PaymentDetailsRequest{OrderID: orderIDs}, false); err != nil {
+			return err
+		}
+	}
+
+	for _, o := range batch {
+		report.Checked++
+		if err, ok := failed[o]; ok {
+			report.Mismatches = append(report.Mismatches, Mismatch{
+				OrderID: o.OrderID, TransactionID: o.TransactionID, Kind: KindLookupFailed, Actual: err.Error(),
+			})
+			continue
+		}
+		var tx *transaction
+		if o.TransactionID != 0 {
+			tx = byTransactionID[o.TransactionID]
+		} else {
+			tx = byOrderID[o.OrderID]
+		}
+		report.Mismatches = append(report.Mismatches, compare(o, tx)...)
+	}
+	return nil
+}
+
+func (r *Reconciler) lookup(ctx context.Context, req *linepay.PaymentDetailsRequest) ([]*transaction, error) {
+	resp, _, err := r.client.PaymentDetails(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.ReturnCode {
+	case linepay.ReturnCodeSuccess:
+	case linepay.ReturnCodeTransactionNotFound:
+		return nil, nil
+	default:
+		return nil, linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage)
+	}
+	var txs []*transaction
+	for _, info := range resp.Info {
+		if info.OriginalTransactionID != 0 {
+			// refund transactions are accounted for in the refundList of the original
+			continue
+		}
+		tx := &transaction{
+			transactionID: info.TransactionID,
+			orderID:       info.OrderID,
+			currency:      info.Currency,
+			payStatus:     info.PayStatus,
+		}
+		for _, p := range info.PayInfo {
+			tx.amount += p.Amount
+		}
+		for _, refund := range info.RefundList {
+			tx.refunded += linepay.RefundedAmount(refund.RefundAmount)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+func compare(o *Order, tx *transaction) []Mismatch {
+	if tx == nil {
+		return []Mismatch{{OrderID: o.OrderID, TransactionID: o.TransactionID, Kind: KindMissingTransaction}}
+	}
+	var list []Mismatch
+	add := func(kind Kind, expected, actual string) {
+		list = append(list, Mismatch{
+			OrderID: o.OrderID, TransactionID: tx.transactionID, Kind: kind, Expected: expected, Actual: actual,
+		})
+	}
+	if o.Amount != tx.amount {
+		add(KindAmountMismatch, strconv.Itoa(o.Amount), strconv.Itoa(tx.amount))
+	}
+	if o.Currency != "" && tx.currency != "" && o.Currency != tx.currency {
+		add(KindCurrencyMismatch, string(o.Currency), string(tx.currency))
+	}
+	if o.State != "" {
+		if actual := tx.state(); actual != o.State {
+			add(KindStatusDrift, string(o.State), string(actual))
+		}
+	}
+	switch {
+	case tx.refunded > o.RefundedAmount:
+		add(KindUnexpectedRefund, strconv.Itoa(o.RefundedAmount), strconv.Itoa(tx.refunded))
+	case tx.refunded < o.RefundedAmount:
+		add(KindMissingRefund, strconv.Itoa(o.RefundedAmount), strconv.Itoa(tx.refunded))
+	}
+	return list
+}
+
+// state maps a PaymentDetails entry to the lifecycle of package state.
+func (tx *transaction) state() state.State {
+	switch tx.payStatus {
+	case linepay.PayStatusAuthorization:
+		return state.StateAuthorized
+	case linepay.PayStatusVoidedAuthorization:
+		return state.StateVoided
+	case linepay.PayStatusExpiredAuthorization:
+		return state.StateExpired
+	}
+	switch {
+	case tx.refunded == 0:
+		return state.StateCaptured
+	case tx.refunded < tx.amount:
+		return state.StatePartiallyRefunded
+	default:
+		return state.StateRefunded
+	}
+}
@@ -0,0 +1,102 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay/state"
+)
+
+func setup(t *testing.T, options ...Option) (*Reconciler, *http.ServeMux, func()) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client, err := linepay.New("testid", "testsecret", linepay.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(client, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, mux, server.Close
+}
+
+func TestReconciler_Run(t *testing.T) {
+	reconciler, mux, teardown := setup(t, WithBatchSize(3))
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"returnCode":"0000","info":[
+			{"transactionId":1,"orderId":"o1","currency":"JPY","payInfo":[{"method":"BALANCE","amount":100}]},
+			{"transactionId":2,"orderId":"o2","currency":"JPY","payInfo":[{"method":"BALANCE","amount":200}]},
+			{"transactionId":3,"orderId":"o3","currency":"JPY","payStatus":"AUTHORIZATION","payInfo":[{"method":"BALANCE","amount":300}]},
+			{"transactionId":4,"orderId":"o4","currency":"JPY","payInfo":[{"method":"BALANCE","amount":400}],"refundList":[{"refundTransactionId":5,"refundAmount":-100}]},
+			{"transactionId":5,"orderId":"o4","currency":"JPY","originalTransactionId":4,"payInfo":[{"method":"BALANCE","amount":-100}]}
+		]}`)
+	})
+
+	orders := []*Order{
+		{OrderID: "o1", TransactionID: 1, Amount: 100, Currency: "JPY", State: state.StateCaptured},
+		{OrderID: "o2", Amount: 250, Currency: "JPY"},
+		{OrderID: "o3", TransactionID: 3, Amount: 300, Currency: "JPY", State: state.StateCaptured},
+		{OrderID: "o4", TransactionID: 4, Amount: 400, Currency: "JPY"},
+		{OrderID: "o6", TransactionID: 6, Amount: 600, Currency: "JPY"},
+	}
+	report, err := reconciler.Run(context.Background(), NewSliceIterator(orders))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 5 {
+		t.Errorf("Checked %d; want 5", report.Checked)
+	}
+	if calls != 3 {
+		t.Errorf("PaymentDetails called %d times; want 3", calls)
+	}
+
+	want := []Mismatch{
+		{OrderID: "o2", TransactionID: 2, Kind: KindAmountMismatch, Expected: "250", Actual: "200"},
+		{OrderID: "o3", TransactionID: 3, Kind: KindStatusDrift, Expected: "CAPTURED", Actual: "AUTHORIZED"},
+		{OrderID: "o4", TransactionID: 4, Kind: KindUnexpectedRefund, Expected: "0", Actual: "100"},
+		{OrderID: "o6", TransactionID: 6, Kind: KindMissingTransaction},
+	}
+	if fmt.Sprint(report.Mismatches) != fmt.Sprint(want) {
+		t.Errorf("Mismatches %+v; want %+v", report.Mismatches, want)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := "order_id,transaction_id,kind,expected,actual\n" +
+		"o2,2,AMOUNT_MISMATCH,250,200\n" +
+		"o3,3,STATUS_DRIFT,CAPTURED,AUTHORIZED\n" +
+		"o4,4,UNEXPECTED_REFUND,0,100\n" +
+		"o6,6,MISSING_TRANSACTION,,\n"
+	if buf.String() != wantCSV {
+		t.Errorf("CSV\n%s\nwant\n%s", buf.String(), wantCSV)
+	}
+}
+
+func TestReconciler_LookupFailed(t *testing.T) {
+	reconciler, mux, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"9000","returnMessage":"internal error"}`)
+	})
+
+	report, err := reconciler.Run(context.Background(), NewSliceIterator([]*Order{{OrderID: "o1", Amount: 100}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Mismatches) != 1 || report.Mismatches[0].Kind != KindLookupFailed {
+		t.Errorf("Mismatches %+v; want one lookup failure", report.Mismatches)
+	}
+}
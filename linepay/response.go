@@ -0,0 +1,63 @@
+package linepay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// DefaultMaxResponseSize is the largest response body read unless WithMaxResponseSize is given.
+const DefaultMaxResponseSize = 10 << 20
+
+// ErrResponseTooLarge is returned when a response body exceeds the maximum response size.
+var ErrResponseTooLarge = errors.New("linepay: response body too large")
+
+// WithMaxResponseSize function
+// WithMaxResponseSize limits the size of the response bodies read by Do.
+func WithMaxResponseSize(n int64) ClientOption {
+	return func(client *Client) error {
+		if n <= 0 {
+			return errors.New("max response size must be positive")
+		}
+		client.maxResponseSize = n
+		return nil
+	}
+}
+
+// RawResponse type
+// RawResponse passed to Client.Do keeps the exact bytes of the response body
+// in Body, e.g. to archive them, and decodes them into Value unless it is nil.
+type RawResponse struct {
+	Body  []byte
+	Value interface{}
+}
+
+// Response type
+type Response[T any] struct {
+	Value *T
+	// Body holds the exact bytes Value was decoded from.
+	Body []byte
+	HTTP *http.Response
+}
+
+// Do function
+// Do sends req with c and decodes the response body into a new T.
+func Do[T any](ctx context.Context, c *Client, req *http.Request) (*T, *http.Response, error) {
+	v := new(T)
+	httpResp, err := c.Do(ctx, req, v)
+	if err != nil {
+		return nil, httpResp, err
+	}
+	return v, httpResp, nil
+}
+
+// DoRaw function
+// DoRaw is like Do but also returns the exact bytes of the response body.
+func DoRaw[T any](ctx context.Context, c *Client, req *http.Request) (*Response[T], error) {
+	raw := &RawResponse{Value: new(T)}
+	httpResp, err := c.Do(ctx, req, raw)
+	if err != nil {
+		return &Response[T]{Body: raw.Body, HTTP: httpResp}, err
+	}
+	return &Response[T]{Value: raw.Value.(*T), Body: raw.Body, HTTP: httpResp}, nil
+}
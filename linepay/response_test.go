@@ -0,0 +1,72 @@
+package linepay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestDo(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	const body = `{ "returnCode": "0000",  "info": {"transactionId": 1} }`
+	mux.HandleFunc("/confirm", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `"`+strings.Repeat("a", 100)+`"`)
+	})
+
+	ctx := context.Background()
+	req, _ := client.NewRequest(http.MethodGet, "/confirm", nil)
+	resp, _, err := Do[ConfirmResponse](ctx, client, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReturnCode != "0000" || resp.Info.TransactionID != 1 {
+		t.Errorf("Do returned %+v", resp)
+	}
+
+	req, _ = client.NewRequest(http.MethodGet, "/confirm", nil)
+	raw, err := DoRaw[ConfirmResponse](ctx, client, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw.Body) != body {
+		t.Errorf("DoRaw body %q; want %q", raw.Body, body)
+	}
+	if raw.Value.Info.TransactionID != 1 || raw.HTTP.StatusCode != http.StatusOK {
+		t.Errorf("DoRaw returned %+v", raw)
+	}
+
+	req, _ = client.NewRequest(http.MethodGet, "/confirm", nil)
+	if _, err := client.Do(ctx, req, failingWriter{}); err == nil || err.Error() != "disk full" {
+		t.Errorf("Do returned %v; want the writer error", err)
+	}
+
+	client.maxResponseSize = 102
+	req, _ = client.NewRequest(http.MethodGet, "/large", nil)
+	if _, err := client.Do(ctx, req, new(string)); err != nil {
+		t.Errorf("Do returned %v for a body of exactly the limit", err)
+	}
+	client.maxResponseSize = 101
+	req, _ = client.NewRequest(http.MethodGet, "/large", nil)
+	if _, err := client.Do(ctx, req, new(string)); err != ErrResponseTooLarge {
+		t.Errorf("Do returned %v; want %v", err, ErrResponseTooLarge)
+	}
+	req, _ = client.NewRequest(http.MethodGet, "/large", nil)
+	if _, err := client.Do(ctx, req, new(bytes.Buffer)); err != ErrResponseTooLarge {
+		t.Errorf("Do returned %v for a writer; want %v", err, ErrResponseTooLarge)
+	}
+}
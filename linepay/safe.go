@@ -0,0 +1,210 @@
+package linepay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Outcome type
+type Outcome string
+
+// Outcome constants
+const (
+	// OutcomeSucceeded means the payment went through.
+	OutcomeSucceeded Outcome = "SUCCEEDED"
+	// OutcomeFailed means LINE Pay rejected the payment and no money moved.
+	OutcomeFailed Outcome = "FAILED"
+	// OutcomeUnknown means neither the call nor PaymentDetails could tell.
+	// The payment must be checked again later before it is retried or given up.
+	OutcomeUnknown Outcome = "UNKNOWN"
+)
+
+// SafeResult type
+// SafeResult is the result of ConfirmSafely and PayPreapprovedSafely.
+type SafeResult struct {
+	Outcome       Outcome
+	TransactionID int64
+	OrderID       string
+	ReturnCode    string
+	ReturnMessage string
+	PayInfo       []struct {
+		Method PayMethod `json:"method"`
+		Amount int       `json:"amount"`
+	}
+	// Resolved is true if the outcome was decided by PaymentDetails rather than the response of the call.
+	Resolved bool
+	// Attempts is the number of times the call was sent.
+	Attempts int
+	// Err is the last error of the call or of PaymentDetails, if any.
+	Err error
+}
+
+// SafeOption type
+type SafeOption func(*safeConfig)
+
+type safeConfig struct {
+	attempts int
+	interval time.Duration
+}
+
+// WithSafeAttempts function
+// WithSafeAttempts sets how many times the call is sent while PaymentDetails
+// shows it did not go through. The default is 2.
+func WithSafeAttempts(n int) SafeOption {
+	return func(c *safeConfig) {
+		if n > 0 {
+			c.attempts = n
+		}
+	}
+}
+
+// WithSafeRetryInterval function
+// WithSafeRetryInterval sets the wait before looking up and sending again. The default is 1 second.
+func WithSafeRetryInterval(d time.Duration) SafeOption {
+	return func(c *safeConfig) {
+		c.interval = d
+	}
+}
+
+// ConfirmSafely method
+// ConfirmSafely calls Confirm. When the call times out, fails in transport or
+// returns an internal error, PaymentDetails is consulted by transactionID to
+// find out whether the payment was completed anyway; if it was not, Confirm
+// is sent again.
+func (c *Client) ConfirmSafely(ctx context.Context, transactionID int64, req *ConfirmRequest, options ...SafeOption) *SafeResult {
+	return c.safely(ctx, options, func() (*SafeResult, error) {
+		resp, _, err := c.Confirm(ctx, transactionID, req)
+		if err != nil {
+			return nil, err
+		}
+		return &SafeResult{
+			TransactionID: resp.Info.TransactionID,
+			OrderID:       resp.Info.OrderID,
+			ReturnCode:    resp.ReturnCode,
+			ReturnMessage: resp.ReturnMessage,
+			PayInfo:       resp.Info.PayInfo,
+		}, nil
+	}, &PaymentDetailsRequest{TransactionID: []int64{transactionID}}, func(details *PaymentDetailsResponse, i int) bool {
+		return details.Info[i].TransactionID == transactionID && details.Info[i].OriginalTransactionID == 0
+	})
+}
+
+// PayPreapprovedSafely method
+// PayPreapprovedSafely calls PayPreapproved. When the call times out, fails in
+// transport, returns an internal error or reports that req.OrderID already
+// exists, PaymentDetails is consulted by req.OrderID to find out whether the
+// payment was made; if it was not, PayPreapproved is sent again.
+func (c *Client) PayPreapprovedSafely(ctx context.Context, regKey string, req *PayPreapprovedRequest, options ...SafeOption) *SafeResult {
+	if req.OrderID == "" {
+		return &SafeResult{Outcome: OutcomeFailed, Err: errors.New("missing order id")}
+	}
+	return c.safely(ctx, options, func() (*SafeResult, error) {
+		resp, _, err := c.PayPreapproved(ctx, regKey, req)
+		if err != nil {
+			return nil, err
+		}
+		return &SafeResult{
+			TransactionID: resp.Info.TransactionID,
+			OrderID:       req.OrderID,
+			ReturnCode:    resp.ReturnCode,
+			ReturnMessage: resp.ReturnMessage,
+		}, nil
+	}, &PaymentDetailsRequest{OrderID: []string{req.OrderID}}, func(details *PaymentDetailsResponse, i int) bool {
+		return details.Info[i].OrderID == req.OrderID && details.Info[i].OriginalTransactionID == 0
+	})
+}
+
+// ResolvedOutcome function
+// ResolvedOutcome tells what the payStatus and refunds of a payment found by
+// PaymentDetails say about a call whose outcome was unknown. Voided and expired
+// authorizations moved no money; a refunded payment, or a status the SDK does
+// not know, is left for a person to check.
+func ResolvedOutcome(payStatus PayStatus, refunded bool) Outcome {
+	switch {
+	case payStatus == PayStatusVoidedAuthorization || payStatus == PayStatusExpiredAuthorization:
+		return OutcomeFailed
+	case refunded:
+		return OutcomeUnknown
+	case payStatus == PayStatusCapture || payStatus == PayStatusAuthorization:
+		return OutcomeSucceeded
+	default:
+		return OutcomeUnknown
+	}
+}
+
+// AmbiguousReturnCode function
+// AmbiguousReturnCode reports whether a returnCode leaves the outcome of a payment call open,
+// so that it has to be looked up with PaymentDetails before the call is sent again.
+func AmbiguousReturnCode(returnCode string) bool {
+	return returnCode == ReturnCodeInternalError || returnCode == ReturnCodeOrderIDExists
+}
+
+func (c *Client) safely(ctx context.Context, options []SafeOption, call func() (*SafeResult, error), lookup *PaymentDetailsRequest, match func(*PaymentDetailsResponse, int) bool) *SafeResult {
+	cfg := &safeConfig{attempts: 2, interval: time.Second}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	var callErr error
+	for attempt := 1; ; attempt++ {
+		result, err := call()
+		if err == nil && !AmbiguousReturnCode(result.ReturnCode) {
+			result.Attempts = attempt
+			result.Outcome = OutcomeFailed
+			if result.ReturnCode == ReturnCodeSuccess {
+				result.Outcome = OutcomeSucceeded
+			}
+			return result
+		}
+		callErr = err
+		if callErr == nil {
+			callErr = CheckReturnCode(result.ReturnCode, result.ReturnMessage)
+		}
+		if ctx.Err() != nil {
+			return &SafeResult{Outcome: OutcomeUnknown, Attempts: attempt, Err: callErr}
+		}
+
+		details, _, err := c.PaymentDetails(ctx, lookup)
+		if err == nil && details.ReturnCode != ReturnCodeTransactionNotFound {
+			err = CheckReturnCode(details.ReturnCode, details.ReturnMessage)
+		}
+		if err != nil {
+			return &SafeResult{Outcome: OutcomeUnknown, Attempts: attempt, Err: err}
+		}
+		for i := range details.Info {
+			if !match(details, i) {
+				continue
+			}
+			info := &details.Info[i]
+			result := &SafeResult{
+				Outcome:       ResolvedOutcome(info.PayStatus, len(info.RefundList) > 0),
+				TransactionID: info.TransactionID,
+				OrderID:       info.OrderID,
+				PayInfo:       info.PayInfo,
+				Resolved:      true,
+				Attempts:      attempt,
+				Err:           callErr,
+			}
+			if result.Outcome == OutcomeSucceeded {
+				result.ReturnCode = ReturnCodeSuccess
+				result.ReturnMessage = details.ReturnMessage
+			} else {
+				result.Err = fmt.Errorf("linepay: payment found with payStatus %s and %d refunds: %v", info.PayStatus, len(info.RefundList), callErr)
+			}
+			return result
+		}
+
+		if attempt >= cfg.attempts {
+			return &SafeResult{Outcome: OutcomeUnknown, Attempts: attempt, Err: callErr}
+		}
+		t := time.NewTimer(cfg.interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return &SafeResult{Outcome: OutcomeUnknown, Attempts: attempt, Err: callErr}
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package linepay
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_ConfirmSafely(t *testing.T) {
+	tests := []struct {
+		name         string
+		confirm      []string // response bodies; "" hangs until the timeout
+		details      string
+		wantOutcome  Outcome
+		wantResolved bool
+		wantAttempts int
+	}{
+		{
+			name:         "success",
+			confirm:      []string{`{"returnCode":"0000","info":{"transactionId":1,"orderId":"o1"}}`},
+			wantOutcome:  OutcomeSucceeded,
+			wantAttempts: 1,
+		},
+		{
+			name:         "rejected",
+			confirm:      []string{`{"returnCode":"1169","returnMessage":"bad"}`},
+			wantOutcome:  OutcomeFailed,
+			wantAttempts: 1,
+		},
+		{
+			name:         "timeout resolved by details",
+			confirm:      []string{""},
+			details:      `{"returnCode":"0000","info":[{"transactionId":1,"orderId":"o1","payStatus":"CAPTURE"}]}`,
+			wantOutcome:  OutcomeSucceeded,
+			wantResolved: true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "timeout resolved as voided",
+			confirm:      []string{""},
+			details:      `{"returnCode":"0000","info":[{"transactionId":1,"orderId":"o1","payStatus":"VOIDED_AUTHORIZATION"}]}`,
+			wantOutcome:  OutcomeFailed,
+			wantResolved: true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "timeout resolved as expired",
+			confirm:      []string{""},
+			details:      `{"returnCode":"0000","info":[{"transactionId":1,"orderId":"o1","payStatus":"EXPIRED_AUTHORIZATION"}]}`,
+			wantOutcome:  OutcomeFailed,
+			wantResolved: true,
+			wantAttempts: 1,
+		},
+		{
+			name:    "timeout resolved as refunded",
+			confirm: []string{""},
+			details: `{"returnCode":"0000","info":[{"transactionId":1,"orderId":"o1","payStatus":"CAPTURE",
+				"refundList":[{"refundTransactionId":2,"refundAmount":100}]}]}`,
+			wantOutcome:  OutcomeUnknown,
+			wantResolved: true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "timeout then retried",
+			confirm:      []string{"", `{"returnCode":"0000","info":{"transactionId":1,"orderId":"o1"}}`},
+			details:      `{"returnCode":"1150","returnMessage":"not found"}`,
+			wantOutcome:  OutcomeSucceeded,
+			wantAttempts: 2,
+		},
+		{
+			name:         "internal errors not found",
+			confirm:      []string{`{"returnCode":"9000"}`, `{"returnCode":"9000"}`},
+			details:      `{"returnCode":"1150","returnMessage":"not found"}`,
+			wantOutcome:  OutcomeUnknown,
+			wantAttempts: 2,
+		},
+		{
+			name:         "details failed",
+			confirm:      []string{`{"returnCode":"9000"}`},
+			details:      `{"returnCode":"9000"}`,
+			wantOutcome:  OutcomeUnknown,
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mux, serverURL, teardown := setup()
+			defer teardown()
+
+			calls := 0
+			mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+				body := tt.confirm[calls]
+				calls++
+				if body == "" {
+					// the connection is only watched for closing once the body is read
+					ioutil.ReadAll(r.Body)
+					<-r.Context().Done()
+					return
+				}
+				fmt.Fprint(w, body)
+			})
+			mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+				if got := r.URL.Query().Get("transactionId"); got != "1" {
+					t.Errorf("transactionId %q; want 1", got)
+				}
+				fmt.Fprint(w, tt.details)
+			})
+
+			client, err := New("testid", "testsecret", WithEndpoint(serverURL), WithTimeouts(Timeouts{OperationConfirm: 20 * time.Millisecond}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := client.ConfirmSafely(context.Background(), 1, &ConfirmRequest{Amount: 100, Currency: "JPY"}, WithSafeRetryInterval(0))
+			if result.Outcome != tt.wantOutcome || result.Resolved != tt.wantResolved || result.Attempts != tt.wantAttempts {
+				t.Errorf("result %+v; want outcome %s, resolved %v, attempts %d", result, tt.wantOutcome, tt.wantResolved, tt.wantAttempts)
+			}
+			if result.Outcome == OutcomeSucceeded && (result.TransactionID != 1 || result.OrderID != "o1") {
+				t.Errorf("result %+v; want transaction 1 of o1", result)
+			}
+			if result.Outcome != OutcomeSucceeded && (result.Resolved || result.Outcome == OutcomeUnknown) && result.Err == nil {
+				t.Errorf("%s result without Err", result.Outcome)
+			}
+		})
+	}
+}
+
+func TestClient_PayPreapprovedSafely(t *testing.T) {
+	_, mux, serverURL, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/preapprovedPay/rk/payment", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"1172","returnMessage":"existing same orderId"}`)
+	})
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("orderId"); got != "o1" {
+			t.Errorf("orderId %q; want o1", got)
+		}
+		fmt.Fprint(w, `{"returnCode":"0000","info":[
+			{"transactionId":2,"orderId":"o1","originalTransactionId":1},
+			{"transactionId":1,"orderId":"o1","payStatus":"CAPTURE","payInfo":[{"method":"CREDIT_CARD","amount":100}]}
+		]}`)
+	})
+
+	client, err := New("testid", "testsecret", WithEndpoint(serverURL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := client.PayPreapprovedSafely(context.Background(), "rk", &PayPreapprovedRequest{OrderID: "o1", Amount: 100, Currency: "JPY"})
+	if result.Outcome != OutcomeSucceeded || !result.Resolved || result.TransactionID != 1 || len(result.PayInfo) != 1 {
+		t.Errorf("result %+v; want transaction 1 resolved by details", result)
+	}
+
+	if result := client.PayPreapprovedSafely(context.Background(), "rk", &PayPreapprovedRequest{}); result.Outcome != OutcomeFailed {
+		t.Errorf("outcome %s without order id; want %s", result.Outcome, OutcomeFailed)
+	}
+}
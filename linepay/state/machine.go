@@ -0,0 +1,253 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+// Payment type
+// Payment is the state of one LINE Pay transaction. Machine updates it in place;
+// callers must not use the same Payment from several goroutines at once.
+type Payment struct {
+	TransactionID  int64            `json:"transactionId"`
+	OrderID        string           `json:"orderId"`
+	Amount         int              `json:"amount"`
+	Currency       linepay.Currency `json:"currency"`
+	AuthorizeOnly  bool             `json:"authorizeOnly"`
+	RefundedAmount int              `json:"refundedAmount"`
+	State          State            `json:"state"`
+}
+
+// Refundable method
+// Refundable returns the amount that can still be refunded.
+func (p *Payment) Refundable() int {
+	if p.State != StateCaptured && p.State != StatePartiallyRefunded {
+		return 0
+	}
+	return p.Amount - p.RefundedAmount
+}
+
+// Transition type
+// Transition is emitted after a payment changed state.
+type Transition struct {
+	Payment Payment   `json:"payment"`
+	Action  Action    `json:"action"`
+	From    State     `json:"from"`
+	To      State     `json:"to"`
+	At      time.Time `json:"at"`
+}
+
+// Listener type
+type Listener func(ctx context.Context, t Transition)
+
+// ErrRefundAmount is returned when a refund exceeds the refundable amount.
+var ErrRefundAmount = errors.New("state: refund amount exceeds refundable amount")
+
+// Machine type
+type Machine struct {
+	client    *linepay.Client
+	listeners []Listener
+	now       func() time.Time
+}
+
+// Option type
+type Option func(*Machine) error
+
+// New returns a new state machine instance.
+func New(client *linepay.Client, options ...Option) (*Machine, error) {
+	if client == nil {
+		return nil, errors.New("missing client")
+	}
+	m := &Machine{
+		client: client,
+		now:    time.Now,
+	}
+	for _, option := range options {
+		err := option(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// WithListener function
+// WithListener registers l to be called synchronously after every transition.
+func WithListener(l Listener) Option {
+	return func(m *Machine) error {
+		m.listeners = append(m.listeners, l)
+		return nil
+	}
+}
+
+// WithClock function
+func WithClock(now func() time.Time) Option {
+	return func(m *Machine) error {
+		m.now = now
+		return nil
+	}
+}
+
+// Request method
+// Request calls the Request API and returns the new payment in StateRequested.
+func (m *Machine) Request(ctx context.Context, req *linepay.RequestRequest) (*Payment, *linepay.RequestResponse, error) {
+	resp, _, err := m.client.Request(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage); err != nil {
+		return nil, resp, err
+	}
+	p := &Payment{
+		TransactionID: resp.Info.TransactionID,
+		OrderID:       req.OrderID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		State:         StateRequested,
+	}
+	if req.Options != nil && req.Options.Payment != nil && req.Options.Payment.Capture != nil {
+		p.AuthorizeOnly = !*req.Options.Payment.Capture
+	}
+	return p, resp, nil
+}
+
+// Confirm method
+// Confirm moves a requested payment to StateAuthorized, or to StateCaptured unless it was requested with capture=false.
+func (m *Machine) Confirm(ctx context.Context, p *Payment) (*linepay.ConfirmResponse, error) {
+	if err := check(p, ActionConfirm); err != nil {
+		return nil, err
+	}
+	resp, _, err := m.client.Confirm(ctx, p.TransactionID, &linepay.ConfirmRequest{
+		Amount:   p.Amount,
+		Currency: p.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage); err != nil {
+		return resp, err
+	}
+	to := StateCaptured
+	if p.AuthorizeOnly {
+		to = StateAuthorized
+	}
+	m.transition(ctx, p, ActionConfirm, to)
+	return resp, nil
+}
+
+// Capture method
+func (m *Machine) Capture(ctx context.Context, p *Payment) (*linepay.CaptureResponse, error) {
+	if err := check(p, ActionCapture); err != nil {
+		return nil, err
+	}
+	resp, _, err := m.client.Capture(ctx, p.TransactionID, &linepay.CaptureRequest{
+		Amount:   p.Amount,
+		Currency: p.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage); err != nil {
+		return resp, err
+	}
+	m.transition(ctx, p, ActionCapture, StateCaptured)
+	return resp, nil
+}
+
+// Void method
+func (m *Machine) Void(ctx context.Context, p *Payment) (*linepay.VoidResponse, error) {
+	if err := check(p, ActionVoid); err != nil {
+		return nil, err
+	}
+	resp, _, err := m.client.Void(ctx, p.TransactionID, &linepay.VoidRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if err := linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage); err != nil {
+		return resp, err
+	}
+	m.transition(ctx, p, ActionVoid, StateVoided)
+	return resp, nil
+}
+
+// Refund method
+// Refund refunds amount of a captured payment. An amount of 0 refunds everything that is left.
+func (m *Machine) Refund(ctx context.Context, p *Payment, amount int) (*linepay.RefundResponse, error) {
+	if err := check(p, ActionRefund); err != nil {
+		return nil, err
+	}
+	refundable := p.Refundable()
+	if amount == 0 {
+		amount = refundable
+	}
+	if amount <= 0 || amount > refundable {
+		return nil, ErrRefundAmount
+	}
+	req := &linepay.RefundRequest{}
+	if amount < p.Amount {
+		req.RefundAmount = amount
+	}
+	resp, _, err := m.client.Refund(ctx, p.TransactionID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage); err != nil {
+		return resp, err
+	}
+	p.RefundedAmount += amount
+	to := StatePartiallyRefunded
+	if p.RefundedAmount == p.Amount {
+		to = StateRefunded
+	}
+	m.transition(ctx, p, ActionRefund, to)
+	return resp, nil
+}
+
+// Expire method
+// Expire records that the payment request or authorization lapsed. It does not call the API.
+func (m *Machine) Expire(ctx context.Context, p *Payment) error {
+	if err := check(p, ActionExpire); err != nil {
+		return err
+	}
+	m.transition(ctx, p, ActionExpire, StateExpired)
+	return nil
+}
+
+// Cancel method
+// Cancel records that the user cancelled the payment on LINE Pay. It does not call the API.
+func (m *Machine) Cancel(ctx context.Context, p *Payment) error {
+	if err := check(p, ActionCancel); err != nil {
+		return err
+	}
+	m.transition(ctx, p, ActionCancel, StateCancelled)
+	return nil
+}
+
+func check(p *Payment, a Action) error {
+	if !p.State.Can(a) {
+		return &TransitionError{From: p.State, Action: a}
+	}
+	return nil
+}
+
+func (m *Machine) transition(ctx context.Context, p *Payment, a Action, to State) {
+	if !CanTransition(p.State, a, to) {
+		panic(fmt.Sprintf("state: undefined transition %s -%s-> %s", p.State, a, to))
+	}
+	from := p.State
+	p.State = to
+	t := Transition{
+		Payment: *p,
+		Action:  a,
+		From:    from,
+		To:      to,
+		At:      m.now(),
+	}
+	for _, l := range m.listeners {
+		l(ctx, t)
+	}
+}
@@ -0,0 +1,99 @@
+// Package state models the lifecycle of a LINE Pay payment.
+//
+//	REQUESTED ─confirm─▶ AUTHORIZED ─capture─▶ CAPTURED ─refund─▶ PARTIALLY_REFUNDED ─refund─▶ REFUNDED
+//	    │                    │  └────void──▶ VOIDED
+//	    │ confirm (capture)  └──expire───▶ EXPIRED
+//	    └───────────────────────────────▶ CAPTURED
+//	REQUESTED ─cancel─▶ CANCELLED, REQUESTED ─expire─▶ EXPIRED
+//
+// Machine drives the transitions with linepay.Client and refuses illegal moves,
+// such as refunding an authorization or voiding a captured payment, before calling the API.
+package state
+
+import (
+	"fmt"
+)
+
+// State type
+type State string
+
+// State constants
+const (
+	StateRequested         State = "REQUESTED"
+	StateAuthorized        State = "AUTHORIZED"
+	StateCaptured          State = "CAPTURED"
+	StatePartiallyRefunded State = "PARTIALLY_REFUNDED"
+	StateRefunded          State = "REFUNDED"
+	StateVoided            State = "VOIDED"
+	StateExpired           State = "EXPIRED"
+	StateCancelled         State = "CANCELLED"
+)
+
+// Action type
+type Action string
+
+// Action constants
+const (
+	ActionConfirm Action = "CONFIRM"
+	ActionCapture Action = "CAPTURE"
+	ActionVoid    Action = "VOID"
+	ActionRefund  Action = "REFUND"
+	ActionExpire  Action = "EXPIRE"
+	ActionCancel  Action = "CANCEL"
+)
+
+// transitions lists the states each action may lead to, per source state.
+var transitions = map[State]map[Action][]State{
+	StateRequested: {
+		ActionConfirm: {StateAuthorized, StateCaptured},
+		ActionExpire:  {StateExpired},
+		ActionCancel:  {StateCancelled},
+	},
+	StateAuthorized: {
+		ActionCapture: {StateCaptured},
+		ActionVoid:    {StateVoided},
+		ActionExpire:  {StateExpired},
+	},
+	StateCaptured: {
+		ActionRefund: {StatePartiallyRefunded, StateRefunded},
+	},
+	StatePartiallyRefunded: {
+		ActionRefund: {StatePartiallyRefunded, StateRefunded},
+	},
+}
+
+// Can method
+// Can reports whether a is allowed in s.
+func (s State) Can(a Action) bool {
+	_, ok := transitions[s][a]
+	return ok
+}
+
+// Terminal method
+// Terminal reports whether no action is allowed in s.
+func (s State) Terminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// CanTransition function
+// CanTransition reports whether a leads from one state to the other.
+func CanTransition(from State, a Action, to State) bool {
+	for _, s := range transitions[from][a] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionError type
+// TransitionError is returned when an action is not allowed in the current state.
+type TransitionError struct {
+	From   State
+	Action Action
+}
+
+// Error method
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("state: %s is not allowed in %s", e.Action, e.From)
+}
@@ -0,0 +1,156 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+func setup(t *testing.T, options ...Option) (*Machine, *http.ServeMux, func()) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client, err := linepay.New("testid", "testsecret", linepay.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(client, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, mux, server.Close
+}
+
+func TestState_Can(t *testing.T) {
+	tests := []struct {
+		state  State
+		action Action
+		want   bool
+	}{
+		{StateRequested, ActionConfirm, true},
+		{StateAuthorized, ActionRefund, false},
+		{StateAuthorized, ActionVoid, true},
+		{StateCaptured, ActionVoid, false},
+		{StateCaptured, ActionRefund, true},
+		{StateRefunded, ActionRefund, false},
+	}
+	for _, tt := range tests {
+		if got := tt.state.Can(tt.action); got != tt.want {
+			t.Errorf("%s.Can(%s) = %v; want %v", tt.state, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestMachine_AuthorizeCaptureRefund(t *testing.T) {
+	var transitions []Transition
+	m, mux, teardown := setup(t, WithListener(func(ctx context.Context, tr Transition) {
+		transitions = append(transitions, tr)
+	}))
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/request", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000","info":{"transactionId":1}}`)
+	})
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	mux.HandleFunc("/v3/payments/authorizations/1/capture", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	var refundAmounts []int
+	mux.HandleFunc("/v3/payments/1/refund", func(w http.ResponseWriter, r *http.Request) {
+		v := new(linepay.RefundRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		refundAmounts = append(refundAmounts, v.RefundAmount)
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	ctx := context.Background()
+	p, _, err := m.Request(ctx, &linepay.RequestRequest{
+		Amount:   100,
+		Currency: "JPY",
+		OrderID:  "order",
+		Options: &linepay.RequestOptions{
+			Payment: &linepay.RequestOptionsPayment{Capture: linepay.Bool(false)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Confirm(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if p.State != StateAuthorized {
+		t.Fatalf("State %s; want %s", p.State, StateAuthorized)
+	}
+	if _, err := m.Refund(ctx, p, 0); err == nil {
+		t.Fatal("Refund of an authorization succeeded")
+	}
+	if _, err := m.Capture(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Void(ctx, p); err == nil {
+		t.Fatal("Void after capture succeeded")
+	}
+	if _, err := m.Refund(ctx, p, 30); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Refund(ctx, p, 80); err != ErrRefundAmount {
+		t.Fatalf("Refund over the refundable amount returned %v; want %v", err, ErrRefundAmount)
+	}
+	if _, err := m.Refund(ctx, p, 0); err != nil {
+		t.Fatal(err)
+	}
+	if p.State != StateRefunded || p.RefundedAmount != 100 {
+		t.Errorf("State %s RefundedAmount %d; want %s 100", p.State, p.RefundedAmount, StateRefunded)
+	}
+	if want := []int{30, 70}; fmt.Sprint(refundAmounts) != fmt.Sprint(want) {
+		t.Errorf("refund amounts %v; want %v", refundAmounts, want)
+	}
+
+	want := []State{StateAuthorized, StateCaptured, StatePartiallyRefunded, StateRefunded}
+	if len(transitions) != len(want) {
+		t.Fatalf("got %d transitions; want %d", len(transitions), len(want))
+	}
+	for i, s := range want {
+		if transitions[i].To != s {
+			t.Errorf("transition %d to %s; want %s", i, transitions[i].To, s)
+		}
+	}
+}
+
+func TestMachine_IllegalMoveSkipsAPI(t *testing.T) {
+	m, mux, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/authorizations/1/void", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Void API called for a captured payment")
+	})
+
+	p := &Payment{TransactionID: 1, Amount: 100, State: StateCaptured}
+	_, err := m.Void(context.Background(), p)
+	if e, ok := err.(*TransitionError); !ok || e.From != StateCaptured || e.Action != ActionVoid {
+		t.Errorf("Void returned %v; want TransitionError", err)
+	}
+}
+
+func TestMachine_APIFailureKeepsState(t *testing.T) {
+	m, mux, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/authorizations/1/capture", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"1179","returnMessage":"status error"}`)
+	})
+
+	p := &Payment{TransactionID: 1, Amount: 100, State: StateAuthorized}
+	if _, err := m.Capture(context.Background(), p); err == nil {
+		t.Fatal("Capture succeeded")
+	}
+	if p.State != StateAuthorized {
+		t.Errorf("State %s; want %s", p.State, StateAuthorized)
+	}
+}
@@ -0,0 +1,121 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by Store for unknown plans and subscriptions.
+var ErrNotFound = errors.New("subscription: not found")
+
+// Store type
+// Store persists plans, subscriptions and charges. Implementations must be safe for concurrent use.
+type Store interface {
+	SavePlan(ctx context.Context, p *Plan) error
+	GetPlan(ctx context.Context, id string) (*Plan, error)
+	Save(ctx context.Context, s *Subscription) error
+	Get(ctx context.Context, id string) (*Subscription, error)
+	// ListDue returns active and past due subscriptions whose NextAttemptAt is at or before now.
+	ListDue(ctx context.Context, now time.Time) ([]*Subscription, error)
+	AddCharge(ctx context.Context, c *Charge) error
+	ListCharges(ctx context.Context, subscriptionID string) ([]*Charge, error)
+}
+
+// MemoryStore type
+type MemoryStore struct {
+	mu            sync.Mutex
+	plans         map[string]Plan
+	subscriptions map[string]Subscription
+	charges       map[string][]Charge
+}
+
+// NewMemoryStore returns a new in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		plans:         make(map[string]Plan),
+		subscriptions: make(map[string]Subscription),
+		charges:       make(map[string][]Charge),
+	}
+}
+
+// SavePlan method
+func (m *MemoryStore) SavePlan(ctx context.Context, p *Plan) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.plans[p.ID] = *p
+	return nil
+}
+
+// GetPlan method
+func (m *MemoryStore) GetPlan(ctx context.Context, id string) (*Plan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.plans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &p, nil
+}
+
+// Save method
+func (m *MemoryStore) Save(ctx context.Context, s *Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscriptions[s.ID] = *s
+	return nil
+}
+
+// Get method
+func (m *MemoryStore) Get(ctx context.Context, id string) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.subscriptions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &s, nil
+}
+
+// ListDue method
+func (m *MemoryStore) ListDue(ctx context.Context, now time.Time) ([]*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []*Subscription
+	for _, s := range m.subscriptions {
+		if s.Status != StatusActive && s.Status != StatusPastDue {
+			continue
+		}
+		if s.NextAttemptAt.After(now) {
+			continue
+		}
+		s := s
+		list = append(list, &s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].NextAttemptAt.Before(list[j].NextAttemptAt)
+	})
+	return list, nil
+}
+
+// AddCharge method
+func (m *MemoryStore) AddCharge(ctx context.Context, c *Charge) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.charges[c.SubscriptionID] = append(m.charges[c.SubscriptionID], *c)
+	return nil
+}
+
+// ListCharges method
+func (m *MemoryStore) ListCharges(ctx context.Context, subscriptionID string) ([]*Charge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]*Charge, 0, len(m.charges[subscriptionID]))
+	for _, c := range m.charges[subscriptionID] {
+		c := c
+		list = append(list, &c)
+	}
+	return list, nil
+}
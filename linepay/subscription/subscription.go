@@ -0,0 +1,383 @@
+// Package subscription bills recurring plans with regKeys obtained from
+// PREAPPROVED payments, retrying failed charges according to a dunning policy.
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+// Interval type
+type Interval struct {
+	Months int `json:"months,omitempty"`
+	Days   int `json:"days,omitempty"`
+}
+
+// Next method
+// Next returns t plus the interval. Months never overflow into the following
+// month: one month after Jan 31 is the last day of February.
+func (i Interval) Next(t time.Time) time.Time {
+	return i.NextFrom(t, t.Day())
+}
+
+// NextFrom method
+// NextFrom is Next for a schedule anchored on day of the month, so that a date
+// clamped to the end of a short month moves back to day in the longer months after it.
+func (i Interval) NextFrom(t time.Time, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(i.Months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location()).AddDate(0, 0, i.Days)
+}
+
+// Monthly is an Interval of one month.
+var Monthly = Interval{Months: 1}
+
+// Plan type
+type Plan struct {
+	ID          string           `json:"id"`
+	ProductName string           `json:"productName"`
+	Amount      int              `json:"amount"`
+	Currency    linepay.Currency `json:"currency"`
+	Interval    Interval         `json:"interval"`
+}
+
+// Status type
+type Status string
+
+// Status constants
+const (
+	StatusActive    Status = "ACTIVE"
+	StatusPastDue   Status = "PAST_DUE"
+	StatusSuspended Status = "SUSPENDED"
+	StatusCancelled Status = "CANCELLED"
+)
+
+// Subscription type
+// AnchorDay is the day of the month billing started on; DueAt falls on it
+// whenever the month has that day and on the last day of the month otherwise.
+type Subscription struct {
+	ID             string    `json:"id"`
+	PlanID         string    `json:"planId"`
+	RegKey         string    `json:"regKey"`
+	Status         Status    `json:"status"`
+	DueAt          time.Time `json:"dueAt"`
+	AnchorDay      int       `json:"anchorDay,omitempty"`
+	NextAttemptAt  time.Time `json:"nextAttemptAt"`
+	FailedAttempts int       `json:"failedAttempts"`
+	LastError      string    `json:"lastError,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Charge type
+// Charge records one PayPreapproved attempt.
+type Charge struct {
+	SubscriptionID string           `json:"subscriptionId"`
+	OrderID        string           `json:"orderId"`
+	Amount         int              `json:"amount"`
+	Currency       linepay.Currency `json:"currency"`
+	TransactionID  int64            `json:"transactionId,omitempty"`
+	Outcome        linepay.Outcome  `json:"outcome,omitempty"`
+	ReturnCode     string           `json:"returnCode,omitempty"`
+	Error          string           `json:"error,omitempty"`
+	AttemptedAt    time.Time        `json:"attemptedAt"`
+}
+
+// DunningPolicy type
+// DunningPolicy describes how failed charges are retried.
+// After len(RetryIntervals) failed retries the subscription is suspended,
+// or cancelled when CancelOnExhaustion is set.
+type DunningPolicy struct {
+	RetryIntervals     []time.Duration
+	CancelOnExhaustion bool
+}
+
+// DefaultDunningPolicy retries after one, three and seven days.
+var DefaultDunningPolicy = DunningPolicy{
+	RetryIntervals: []time.Duration{24 * time.Hour, 72 * time.Hour, 168 * time.Hour},
+}
+
+// Errors
+var (
+	ErrNotActive = errors.New("subscription: not active")
+)
+
+// Runner type
+type Runner struct {
+	client   *linepay.Client
+	store    Store
+	dunning  DunningPolicy
+	interval time.Duration
+	orderID  func(s *Subscription) string
+	now      func() time.Time
+}
+
+// Option type
+type Option func(*Runner) error
+
+// New returns a new runner instance.
+func New(client *linepay.Client, store Store, options ...Option) (*Runner, error) {
+	if client == nil {
+		return nil, errors.New("missing client")
+	}
+	if store == nil {
+		return nil, errors.New("missing store")
+	}
+	r := &Runner{
+		client:   client,
+		store:    store,
+		dunning:  DefaultDunningPolicy,
+		interval: time.Minute,
+		orderID:  defaultOrderID,
+		now:      time.Now,
+	}
+	for _, option := range options {
+		err := option(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// WithDunningPolicy function
+func WithDunningPolicy(p DunningPolicy) Option {
+	return func(r *Runner) error {
+		r.dunning = p
+		return nil
+	}
+}
+
+// WithInterval function
+// WithInterval sets how often Run polls the store. Defaults to one minute.
+func WithInterval(d time.Duration) Option {
+	return func(r *Runner) error {
+		if d <= 0 {
+			return errors.New("subscription: interval must be positive")
+		}
+		r.interval = d
+		return nil
+	}
+}
+
+// WithOrderIDFunc function
+// WithOrderIDFunc sets how orderId is built for a charge. It must be the same
+// for every attempt of a billing period and unique across periods, so that
+// LINE Pay rejects a retry of a period that was already paid.
+func WithOrderIDFunc(f func(s *Subscription) string) Option {
+	return func(r *Runner) error {
+		r.orderID = f
+		return nil
+	}
+}
+
+// WithClock function
+func WithClock(now func() time.Time) Option {
+	return func(r *Runner) error {
+		r.now = now
+		return nil
+	}
+}
+
+func defaultOrderID(s *Subscription) string {
+	return fmt.Sprintf("%s-%s", s.ID, s.DueAt.UTC().Format("20060102"))
+}
+
+// Subscribe method
+// Subscribe starts billing regKey for planID. The first charge is made at start.
+func (r *Runner) Subscribe(ctx context.Context, id, planID, regKey string, start time.Time) (*Subscription, error) {
+	if _, err := r.store.GetPlan(ctx, planID); err != nil {
+		return nil, err
+	}
+	now := r.now()
+	s := &Subscription{
+		ID:            id,
+		PlanID:        planID,
+		RegKey:        regKey,
+		Status:        StatusActive,
+		DueAt:         start,
+		AnchorDay:     start.Day(),
+		NextAttemptAt: start,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := r.store.Save(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Cancel method
+// Cancel expires the regKey of the subscription and stops billing it.
+func (r *Runner) Cancel(ctx context.Context, id string) error {
+	s, err := r.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.Status == StatusCancelled {
+		return nil
+	}
+	return r.cancel(ctx, s)
+}
+
+func (r *Runner) cancel(ctx context.Context, s *Subscription) error {
+	resp, _, err := r.client.ExpireRegKey(ctx, s.RegKey, &linepay.ExpireRegKeyRequest{})
+	if err != nil {
+		return err
+	}
+	switch resp.ReturnCode {
+	case linepay.ReturnCodeSuccess, linepay.ReturnCodeRegKeyNotFound, linepay.ReturnCodeRegKeyExpired:
+	default:
+		return linepay.CheckReturnCode(resp.ReturnCode, resp.ReturnMessage)
+	}
+	s.Status = StatusCancelled
+	s.UpdatedAt = r.now()
+	return r.store.Save(ctx, s)
+}
+
+// Run method
+// Run calls RunOnce every interval until ctx is done.
+func (r *Runner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.RunOnce(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce method
+// RunOnce charges every active or past due subscription whose next attempt is due.
+func (r *Runner) RunOnce(ctx context.Context) error {
+	due, err := r.store.ListDue(ctx, r.now())
+	if err != nil {
+		return err
+	}
+	for _, s := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.Bill(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bill method
+// Bill checks the regKey and charges the current period of s.
+// A failed charge is recorded on s and scheduled according to the dunning policy.
+// A charge whose outcome is unknown even after looking it up with PaymentDetails
+// is not counted as failed and is attempted again with the same orderId on the
+// next poll. The returned error only reports storage failures.
+func (r *Runner) Bill(ctx context.Context, s *Subscription) error {
+	if s.Status != StatusActive && s.Status != StatusPastDue {
+		return ErrNotActive
+	}
+	plan, err := r.store.GetPlan(ctx, s.PlanID)
+	if err != nil {
+		return err
+	}
+	charge := &Charge{
+		SubscriptionID: s.ID,
+		OrderID:        r.orderID(s),
+		Amount:         plan.Amount,
+		Currency:       plan.Currency,
+	}
+	chargeErr := r.charge(ctx, s, plan, charge)
+	now := r.now()
+	charge.AttemptedAt = now
+	if chargeErr != nil {
+		charge.Error = chargeErr.Error()
+	}
+	if err := r.store.AddCharge(ctx, charge); err != nil {
+		return err
+	}
+
+	s.UpdatedAt = now
+	if chargeErr == nil {
+		s.Status = StatusActive
+		s.FailedAttempts = 0
+		s.LastError = ""
+		anchor := s.AnchorDay
+		if anchor == 0 {
+			anchor = s.DueAt.Day()
+		}
+		s.DueAt = plan.Interval.NextFrom(s.DueAt, anchor)
+		s.NextAttemptAt = s.DueAt
+		return r.store.Save(ctx, s)
+	}
+
+	s.LastError = chargeErr.Error()
+	if charge.Outcome == linepay.OutcomeUnknown {
+		s.NextAttemptAt = now.Add(r.interval)
+		return r.store.Save(ctx, s)
+	}
+	if isRegKeyUnusable(charge.ReturnCode) {
+		s.Status = StatusSuspended
+		return r.store.Save(ctx, s)
+	}
+	s.FailedAttempts++
+	if s.FailedAttempts > len(r.dunning.RetryIntervals) {
+		if r.dunning.CancelOnExhaustion {
+			if err := r.cancel(ctx, s); err == nil {
+				return nil
+			}
+		}
+		s.Status = StatusSuspended
+		return r.store.Save(ctx, s)
+	}
+	s.Status = StatusPastDue
+	s.NextAttemptAt = now.Add(r.dunning.RetryIntervals[s.FailedAttempts-1])
+	return r.store.Save(ctx, s)
+}
+
+func (r *Runner) charge(ctx context.Context, s *Subscription, plan *Plan, charge *Charge) error {
+	checkResp, _, err := r.client.CheckRegKey(ctx, s.RegKey, &linepay.CheckRegKeyRequest{})
+	if err != nil {
+		return err
+	}
+	if err := linepay.CheckReturnCode(checkResp.ReturnCode, checkResp.ReturnMessage); err != nil {
+		charge.ReturnCode = checkResp.ReturnCode
+		return err
+	}
+	// Timeouts, internal errors and a duplicate orderId are resolved with
+	// PaymentDetails instead of being counted as failed, as the money may have moved.
+	result := r.client.PayPreapprovedSafely(ctx, s.RegKey, &linepay.PayPreapprovedRequest{
+		ProductName: plan.ProductName,
+		Amount:      plan.Amount,
+		Currency:    plan.Currency,
+		OrderID:     charge.OrderID,
+		Capture:     linepay.Bool(true),
+	})
+	charge.Outcome = result.Outcome
+	charge.ReturnCode = result.ReturnCode
+	charge.TransactionID = result.TransactionID
+	switch result.Outcome {
+	case linepay.OutcomeSucceeded:
+		return nil
+	case linepay.OutcomeFailed:
+		if result.Err != nil {
+			return result.Err
+		}
+		return linepay.CheckReturnCode(result.ReturnCode, result.ReturnMessage)
+	default:
+		return fmt.Errorf("subscription: charge outcome unknown: %v", result.Err)
+	}
+}
+
+func isRegKeyUnusable(returnCode string) bool {
+	return returnCode == linepay.ReturnCodeRegKeyNotFound || returnCode == linepay.ReturnCodeRegKeyExpired
+}
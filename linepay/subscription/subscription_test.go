@@ -0,0 +1,314 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gotokatsuya/line-pay-sdk-go/linepay"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func setup(t *testing.T, options ...Option) (*Runner, *MemoryStore, *http.ServeMux, *fakeClock, func()) {
+	return setupClient(t, nil, options...)
+}
+
+func setupClient(t *testing.T, clientOptions []linepay.ClientOption, options ...Option) (*Runner, *MemoryStore, *http.ServeMux, *fakeClock, func()) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	client, err := linepay.New("testid", "testsecret", append([]linepay.ClientOption{linepay.WithEndpoint(server.URL)}, clientOptions...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryStore()
+	if err := store.SavePlan(context.Background(), &Plan{ID: "prime", ProductName: "Prime", Amount: 250, Currency: "JPY", Interval: Monthly}); err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	options = append([]Option{WithClock(clock.Now)}, options...)
+	runner, err := New(client, store, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runner, store, mux, clock, server.Close
+}
+
+func TestRunner_Bill(t *testing.T) {
+	runner, store, mux, clock, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/check", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	var orderIDs []string
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/payment", func(w http.ResponseWriter, r *http.Request) {
+		v := new(linepay.PayPreapprovedRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if v.Amount != 250 || v.Currency != "JPY" || v.ProductName != "Prime" {
+			t.Errorf("Request body = %+v", v)
+		}
+		orderIDs = append(orderIDs, v.OrderID)
+		fmt.Fprint(w, `{"returnCode":"0000","info":{"transactionId":1}}`)
+	})
+
+	ctx := context.Background()
+	if _, err := runner.Subscribe(ctx, "sub1", "prime", "REGKEY", clock.now); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(orderIDs) != 1 {
+		t.Fatalf("charged %d times; want 1", len(orderIDs))
+	}
+	s, _ := store.Get(ctx, "sub1")
+	if want := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC); !s.DueAt.Equal(want) {
+		t.Errorf("DueAt %v; want %v", s.DueAt, want)
+	}
+
+	clock.now = time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	if err := runner.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(orderIDs) != 2 || orderIDs[0] == orderIDs[1] {
+		t.Errorf("orderIDs %v; want two distinct", orderIDs)
+	}
+}
+
+func TestRunner_Dunning(t *testing.T) {
+	runner, store, mux, clock, teardown := setup(t, WithDunningPolicy(DunningPolicy{
+		RetryIntervals:     []time.Duration{time.Hour},
+		CancelOnExhaustion: true,
+	}))
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/check", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/payment", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"1142","returnMessage":"Insufficient balance"}`)
+	})
+	expired := false
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/expire", func(w http.ResponseWriter, r *http.Request) {
+		expired = true
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	ctx := context.Background()
+	if _, err := runner.Subscribe(ctx, "sub1", "prime", "REGKEY", clock.now); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := store.Get(ctx, "sub1")
+	if s.Status != StatusPastDue || !s.NextAttemptAt.Equal(clock.now.Add(time.Hour)) {
+		t.Fatalf("Status %s NextAttemptAt %v; want %s one hour later", s.Status, s.NextAttemptAt, StatusPastDue)
+	}
+
+	clock.now = clock.now.Add(time.Hour)
+	if err := runner.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s, _ = store.Get(ctx, "sub1")
+	if s.Status != StatusCancelled || !expired {
+		t.Errorf("Status %s expired %v; want %s true", s.Status, expired, StatusCancelled)
+	}
+	charges, _ := store.ListCharges(ctx, "sub1")
+	if len(charges) != 2 || charges[0].ReturnCode != "1142" {
+		t.Errorf("charges %+v; want two failed charges", charges)
+	}
+}
+
+func TestRunner_RegKeyExpired(t *testing.T) {
+	runner, store, mux, clock, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/check", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"1193","returnMessage":"expired"}`)
+	})
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/payment", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("PayPreapproved called with expired regKey")
+	})
+
+	ctx := context.Background()
+	if _, err := runner.Subscribe(ctx, "sub1", "prime", "REGKEY", clock.now); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := store.Get(ctx, "sub1")
+	if s.Status != StatusSuspended {
+		t.Errorf("Status %s; want %s", s.Status, StatusSuspended)
+	}
+}
+
+func TestRunner_BillTimeoutResolved(t *testing.T) {
+	runner, store, mux, clock, teardown := setupClient(t, []linepay.ClientOption{
+		linepay.WithTimeouts(linepay.Timeouts{linepay.OperationPayPreapproved: 50 * time.Millisecond}),
+	})
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/check", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	var charged int
+	var orderID string
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/payment", func(w http.ResponseWriter, r *http.Request) {
+		charged++
+		v := new(linepay.PayPreapprovedRequest)
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, v)
+		orderID = v.OrderID
+		// LINE Pay takes the money but the answer never arrives.
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("orderId"); got != orderID {
+			t.Errorf("looked up orderId %q; want %q", got, orderID)
+		}
+		fmt.Fprintf(w, `{"returnCode":"0000","info":[{"transactionId":7,"orderId":%q,"payStatus":"CAPTURE"}]}`, orderID)
+	})
+
+	ctx := context.Background()
+	if _, err := runner.Subscribe(ctx, "sub1", "prime", "REGKEY", clock.now); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	clock.now = clock.now.Add(time.Hour)
+	if err := runner.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if charged != 1 {
+		t.Errorf("charged %d times; want 1", charged)
+	}
+	s, _ := store.Get(ctx, "sub1")
+	if s.Status != StatusActive || s.FailedAttempts != 0 {
+		t.Errorf("Status %s FailedAttempts %d; want %s 0", s.Status, s.FailedAttempts, StatusActive)
+	}
+	if want := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC); !s.DueAt.Equal(want) {
+		t.Errorf("DueAt %v; want %v", s.DueAt, want)
+	}
+	charges, _ := store.ListCharges(ctx, "sub1")
+	if len(charges) != 1 || charges[0].Outcome != linepay.OutcomeSucceeded || charges[0].TransactionID != 7 {
+		t.Errorf("charges %+v; want one succeeded charge of transaction 7", charges)
+	}
+}
+
+func TestRunner_BillUnknown(t *testing.T) {
+	runner, store, mux, clock, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/check", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	var orderIDs []string
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/payment", func(w http.ResponseWriter, r *http.Request) {
+		v := new(linepay.PayPreapprovedRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		orderIDs = append(orderIDs, v.OrderID)
+		fmt.Fprint(w, `{"returnCode":"9000","returnMessage":"Internal error"}`)
+	})
+	mux.HandleFunc("/v3/payments", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx := context.Background()
+	if _, err := runner.Subscribe(ctx, "sub1", "prime", "REGKEY", clock.now); err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := store.Get(ctx, "sub1")
+	if s.Status != StatusActive || s.FailedAttempts != 0 {
+		t.Errorf("Status %s FailedAttempts %d; want %s 0", s.Status, s.FailedAttempts, StatusActive)
+	}
+
+	clock.now = s.NextAttemptAt
+	if err := runner.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(orderIDs) != 2 || orderIDs[0] != orderIDs[1] {
+		t.Errorf("orderIDs %v; want the same orderId twice", orderIDs)
+	}
+	charges, _ := store.ListCharges(ctx, "sub1")
+	if len(charges) != 2 || charges[0].Outcome != linepay.OutcomeUnknown {
+		t.Errorf("charges %+v; want two unknown charges", charges)
+	}
+}
+
+func TestInterval_NextFrom(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		interval Interval
+		t        time.Time
+		day      int
+		want     time.Time
+	}{
+		{Monthly, date(2021, 1, 31), 31, date(2021, 2, 28)},
+		{Monthly, date(2021, 2, 28), 31, date(2021, 3, 31)},
+		{Monthly, date(2024, 1, 31), 31, date(2024, 2, 29)},
+		{Monthly, date(2024, 2, 29), 31, date(2024, 3, 31)},
+		{Monthly, date(2021, 3, 31), 31, date(2021, 4, 30)},
+		{Interval{Months: 12}, date(2024, 2, 29), 29, date(2025, 2, 28)},
+		{Monthly, date(2021, 12, 15), 15, date(2022, 1, 15)},
+		{Interval{Days: 7}, date(2021, 1, 28), 28, date(2021, 2, 4)},
+	}
+	for _, tt := range tests {
+		if got := tt.interval.NextFrom(tt.t, tt.day); !got.Equal(tt.want) {
+			t.Errorf("%+v.NextFrom(%s, %d) = %s; want %s", tt.interval, tt.t.Format("2006-01-02"), tt.day, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+	if got := Monthly.Next(date(2021, 1, 31)); !got.Equal(date(2021, 2, 28)) {
+		t.Errorf("Next(2021-01-31) = %s; want 2021-02-28", got.Format("2006-01-02"))
+	}
+}
+
+func TestRunner_BillEndOfMonth(t *testing.T) {
+	runner, store, mux, clock, teardown := setup(t)
+	defer teardown()
+
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/check", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+	mux.HandleFunc("/v3/payments/preapprovedPay/REGKEY/payment", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000","info":{"transactionId":1}}`)
+	})
+
+	ctx := context.Background()
+	clock.now = time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC)
+	if _, err := runner.Subscribe(ctx, "sub1", "prime", "REGKEY", clock.now); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []time.Time{
+		time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 3, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 4, 30, 0, 0, 0, 0, time.UTC),
+	} {
+		if err := runner.RunOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		s, _ := store.Get(ctx, "sub1")
+		if !s.DueAt.Equal(want) {
+			t.Fatalf("DueAt %s; want %s", s.DueAt.Format("2006-01-02"), want.Format("2006-01-02"))
+		}
+		clock.now = want
+	}
+}
@@ -0,0 +1,136 @@
+package linepay
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Operation type
+// Operation names an API call, used to look up its timeout.
+type Operation string
+
+// Operation constants
+const (
+	OperationRequest            Operation = "request"
+	OperationConfirm            Operation = "confirm"
+	OperationCapture            Operation = "capture"
+	OperationVoid               Operation = "void"
+	OperationRefund             Operation = "refund"
+	OperationPaymentDetails     Operation = "paymentDetails"
+	OperationCheckPaymentStatus Operation = "checkPaymentStatus"
+	OperationPayPreapproved     Operation = "payPreapproved"
+	OperationCheckRegKey        Operation = "checkRegKey"
+	OperationExpireRegKey       Operation = "expireRegKey"
+	OperationCall               Operation = "call"
+)
+
+// Timeouts type
+// Timeouts maps an operation to the time allowed for its call. A missing or zero entry means no limit.
+type Timeouts map[Operation]time.Duration
+
+// DefaultTimeouts are the read timeouts recommended by the LINE Pay documentation.
+// OperationCall gets the longest of them, as the endpoint it calls is not known.
+var DefaultTimeouts = Timeouts{
+	OperationRequest:            20 * time.Second,
+	OperationConfirm:            40 * time.Second,
+	OperationCapture:            60 * time.Second,
+	OperationVoid:               20 * time.Second,
+	OperationRefund:             20 * time.Second,
+	OperationPaymentDetails:     20 * time.Second,
+	OperationCheckPaymentStatus: 20 * time.Second,
+	OperationPayPreapproved:     40 * time.Second,
+	OperationCheckRegKey:        20 * time.Second,
+	OperationExpireRegKey:       20 * time.Second,
+	OperationCall:               60 * time.Second,
+}
+
+// WithTimeouts function
+// WithTimeouts overrides DefaultTimeouts for the operations in t.
+// A timeout only applies if the context passed to the call has no earlier deadline.
+func WithTimeouts(t Timeouts) ClientOption {
+	return func(client *Client) error {
+		timeouts := make(Timeouts, len(DefaultTimeouts)+len(t))
+		for op, d := range client.timeouts {
+			timeouts[op] = d
+		}
+		for op, d := range t {
+			if d < 0 {
+				return fmt.Errorf("negative timeout for %s", op)
+			}
+			timeouts[op] = d
+		}
+		client.timeouts = timeouts
+		return nil
+	}
+}
+
+// TimeoutError type
+// TimeoutError is returned when a call runs out of time.
+// Limit is the operation timeout; Caller is true if the deadline of the caller's context fired instead.
+type TimeoutError struct {
+	Operation Operation
+	Limit     time.Duration
+	Caller    bool
+}
+
+// Error method
+func (e *TimeoutError) Error() string {
+	op := e.Operation
+	if op == "" {
+		op = "request"
+	}
+	if e.Caller {
+		return fmt.Sprintf("linepay: %s exceeded the context deadline", op)
+	}
+	return fmt.Sprintf("linepay: %s exceeded the operation timeout of %s", op, e.Limit)
+}
+
+// Timeout method
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// Unwrap method
+// Unwrap lets errors.Is(err, context.DeadlineExceeded) hold for a TimeoutError.
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+type operationKey struct{}
+
+// withOperation tags ctx with the operation of an API method.
+func withOperation(ctx context.Context, op Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+func operationFrom(ctx context.Context) Operation {
+	op, _ := ctx.Value(operationKey{}).(Operation)
+	return op
+}
+
+// callTimeout bounds ctx by the timeout of its operation unless ctx already ends sooner.
+// applied reports whether the operation timeout became the effective deadline.
+func (c *Client) callTimeout(ctx context.Context) (_ context.Context, cancel context.CancelFunc, timeout time.Duration, applied bool) {
+	timeout = c.timeouts[operationFrom(ctx)]
+	if cfg := callConfigFrom(ctx); cfg.timeout > 0 {
+		timeout = cfg.timeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}, 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return ctx, func() {}, timeout, false
+	}
+	ctx, cancel = context.WithTimeout(ctx, timeout)
+	return ctx, cancel, timeout, true
+}
+
+// timeoutError turns a deadline error of a call into *TimeoutError naming the limit that fired.
+func timeoutError(parent context.Context, op Operation, timeout time.Duration, applied bool, err error) error {
+	if err != context.DeadlineExceeded {
+		return err
+	}
+	caller := parent.Err() == context.DeadlineExceeded || !applied
+	return &TimeoutError{Operation: op, Limit: timeout, Caller: caller}
+}
@@ -0,0 +1,81 @@
+package linepay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_Timeouts(t *testing.T) {
+	_, mux, serverURL, teardown := setup()
+	defer teardown()
+
+	release := make(chan struct{})
+	defer close(release)
+	mux.HandleFunc("/v3/payments/1/confirm", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/v3/payments/authorizations/1/capture", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"returnCode":"0000"}`)
+	})
+
+	client, err := New("testid", "testsecret", WithEndpoint(serverURL), WithTimeouts(Timeouts{
+		OperationConfirm: 20 * time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := client.timeouts[OperationCapture], DefaultTimeouts[OperationCapture]; got != want {
+		t.Errorf("capture timeout %s; want default %s", got, want)
+	}
+	if client.timeouts[OperationCall] <= 0 {
+		t.Error("Call has no default timeout")
+	}
+
+	tests := []struct {
+		name       string
+		ctxTimeout time.Duration
+		wantCaller bool
+	}{
+		{"operation timeout", 0, false},
+		{"looser caller deadline", time.Second, false},
+		{"tighter caller deadline", 10 * time.Millisecond, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+			_, _, err := client.Confirm(ctx, 1, &ConfirmRequest{})
+			var terr *TimeoutError
+			if !errors.As(err, &terr) {
+				t.Fatalf("Confirm returned %v; want *TimeoutError", err)
+			}
+			if terr.Operation != OperationConfirm || terr.Limit != 20*time.Millisecond || terr.Caller != tt.wantCaller {
+				t.Errorf("TimeoutError %+v; want caller %v", terr, tt.wantCaller)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Error("TimeoutError does not match context.DeadlineExceeded")
+			}
+		})
+	}
+
+	if _, _, err := client.Capture(context.Background(), 1, &CaptureRequest{}); err != nil {
+		t.Errorf("Capture returned %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := client.Confirm(ctx, 1, &ConfirmRequest{}); err != context.Canceled {
+		t.Errorf("Confirm returned %v; want %v", err, context.Canceled)
+	}
+}
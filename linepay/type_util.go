@@ -24,3 +24,12 @@ func MustParseInt64(v string) int64 {
 	i, _ := strconv.ParseInt(v, 10, 64)
 	return i
 }
+
+// RefundedAmount refundAmount to positive amount function
+// RefundedAmount returns the amount of a refundList entry, whose refundAmount LINE Pay reports as a negative number.
+func RefundedAmount(refundAmount int) int {
+	if refundAmount < 0 {
+		return -refundAmount
+	}
+	return refundAmount
+}
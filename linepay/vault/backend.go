@@ -0,0 +1,157 @@
+package vault
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backend type
+// Backend stores opaque encrypted blobs. Implementations must be safe for concurrent use.
+type Backend interface {
+	Put(ctx context.Context, id string, blob []byte) error
+	// Get returns ErrNotFound for unknown ids.
+	Get(ctx context.Context, id string) ([]byte, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]string, error)
+}
+
+// MemoryBackend type
+type MemoryBackend struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// NewMemoryBackend returns a new in-memory backend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{blobs: make(map[string][]byte)}
+}
+
+// Put method
+func (b *MemoryBackend) Put(ctx context.Context, id string, blob []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.blobs[id] = append([]byte(nil), blob...)
+	return nil
+}
+
+// Get method
+func (b *MemoryBackend) Get(ctx context.Context, id string) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	blob, ok := b.blobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), blob...), nil
+}
+
+// Delete method
+func (b *MemoryBackend) Delete(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.blobs, id)
+	return nil
+}
+
+// List method
+func (b *MemoryBackend) List(ctx context.Context) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	ids := make([]string, 0, len(b.blobs))
+	for id := range b.blobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// FileBackend type
+// FileBackend stores one file per id in a directory, readable by the owner only.
+type FileBackend struct {
+	dir string
+}
+
+const fileBackendExt = ".regkey"
+
+// NewFileBackend returns a new file backend rooted at dir, creating it if necessary.
+func NewFileBackend(dir string) (*FileBackend, error) {
+	if dir == "" {
+		return nil, errors.New("missing directory")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileBackend{dir: dir}, nil
+}
+
+func (b *FileBackend) path(id string) string {
+	return filepath.Join(b.dir, hex.EncodeToString([]byte(id))+fileBackendExt)
+}
+
+// Put method
+// Put writes the blob to a temporary file and renames it so readers never see partial data.
+func (b *FileBackend) Put(ctx context.Context, id string, blob []byte) error {
+	f, err := ioutil.TempFile(b.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(blob); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), b.path(id))
+}
+
+// Get method
+func (b *FileBackend) Get(ctx context.Context, id string) ([]byte, error) {
+	blob, err := ioutil.ReadFile(b.path(id))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return blob, err
+}
+
+// Delete method
+func (b *FileBackend) Delete(ctx context.Context, id string) error {
+	err := os.Remove(b.path(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// List method
+func (b *FileBackend) List(ctx context.Context) ([]string, error) {
+	infos, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, fileBackendExt) {
+			continue
+		}
+		id, err := hex.DecodeString(strings.TrimSuffix(name, fileBackendExt))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, string(id))
+	}
+	return ids, nil
+}
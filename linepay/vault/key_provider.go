@@ -0,0 +1,83 @@
+package vault
+
+import (
+	"context"
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// KeyProvider type
+// KeyProvider supplies key encryption keys. CurrentKey is used to wrap new
+// data keys; Key must keep returning retired keys until Reencrypt has run.
+type KeyProvider interface {
+	CurrentKey(ctx context.Context) (id string, key []byte, err error)
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// StaticKeyProvider type
+// StaticKeyProvider holds key encryption keys in memory. It is safe for concurrent use.
+type StaticKeyProvider struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+var _ KeyProvider = (*StaticKeyProvider)(nil)
+
+// NewStaticKeyProvider returns a new static key provider whose current key is key.
+// key must be 16, 24 or 32 bytes long.
+func NewStaticKeyProvider(id string, key []byte) (*StaticKeyProvider, error) {
+	p := &StaticKeyProvider{keys: make(map[string][]byte)}
+	if err := p.Rotate(id, key); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Rotate method
+// Rotate adds key and makes it the current key. Previous keys remain available for decryption.
+func (p *StaticKeyProvider) Rotate(id string, key []byte) error {
+	if id == "" {
+		return errors.New("missing key id")
+	}
+	if _, err := aes.NewCipher(key); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[id] = append([]byte(nil), key...)
+	p.current = id
+	return nil
+}
+
+// Retire method
+// Retire removes a key that is no longer current.
+func (p *StaticKeyProvider) Retire(id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if id == p.current {
+		return errors.New("vault: cannot retire the current key")
+	}
+	delete(p.keys, id)
+	return nil
+}
+
+// CurrentKey method
+func (p *StaticKeyProvider) CurrentKey(ctx context.Context) (string, []byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current, p.keys[p.current], nil
+}
+
+// Key method
+func (p *StaticKeyProvider) Key(ctx context.Context, id string) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("vault: unknown key %q", id)
+	}
+	return key, nil
+}
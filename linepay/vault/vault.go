@@ -0,0 +1,223 @@
+// Package vault stores regKeys encrypted at rest.
+//
+// A regKey lets a merchant charge the customer without further approval, so it
+// should be handled like a credential. EncryptedStore encrypts every regKey with
+// its own data key (AES-256-GCM) and wraps that data key with a key encryption
+// key obtained from a KeyProvider, so that key encryption keys can be rotated
+// without decrypting stored regKeys in bulk.
+package vault
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotFound is returned when no regKey is stored for an id.
+var ErrNotFound = errors.New("vault: regKey not found")
+
+// RegKeyStore type
+// RegKeyStore keeps regKeys keyed by an application defined id such as a user id.
+type RegKeyStore interface {
+	Put(ctx context.Context, id, regKey string) error
+	Get(ctx context.Context, id string) (string, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// EncryptedStore type
+// EncryptedStore is a RegKeyStore that envelope-encrypts regKeys before handing them to a Backend.
+type EncryptedStore struct {
+	backend Backend
+	keys    KeyProvider
+}
+
+var _ RegKeyStore = (*EncryptedStore)(nil)
+
+// NewEncryptedStore returns a new encrypted store instance.
+func NewEncryptedStore(backend Backend, keys KeyProvider) (*EncryptedStore, error) {
+	if backend == nil {
+		return nil, errors.New("missing backend")
+	}
+	if keys == nil {
+		return nil, errors.New("missing key provider")
+	}
+	return &EncryptedStore{backend: backend, keys: keys}, nil
+}
+
+// envelope is the serialized form of an encrypted regKey.
+type envelope struct {
+	Version    int    `json:"v"`
+	KeyID      string `json:"kid"`
+	WrappedKey []byte `json:"wrappedKey"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+const envelopeVersion = 1
+
+// Put method
+func (s *EncryptedStore) Put(ctx context.Context, id, regKey string) error {
+	keyID, kek, err := s.keys.CurrentKey(ctx)
+	if err != nil {
+		return err
+	}
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return err
+	}
+	ciphertext, err := seal(dataKey, []byte(regKey), []byte(id))
+	if err != nil {
+		return err
+	}
+	wrapped, err := seal(kek, dataKey, []byte(keyID))
+	if err != nil {
+		return err
+	}
+	return s.write(ctx, id, &envelope{
+		Version:    envelopeVersion,
+		KeyID:      keyID,
+		WrappedKey: wrapped,
+		Ciphertext: ciphertext,
+	})
+}
+
+// Get method
+func (s *EncryptedStore) Get(ctx context.Context, id string) (string, error) {
+	env, err := s.read(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := s.unwrap(ctx, env)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataKey, env.Ciphertext, []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("vault: decrypt regKey %q: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// Delete method
+func (s *EncryptedStore) Delete(ctx context.Context, id string) error {
+	return s.backend.Delete(ctx, id)
+}
+
+// Reencrypt method
+// Reencrypt rewraps every data key that is not wrapped by the current key
+// encryption key and returns how many entries were rewritten.
+// Run it after rotating keys so that retired keys can be removed from the KeyProvider.
+func (s *EncryptedStore) Reencrypt(ctx context.Context) (int, error) {
+	keyID, kek, err := s.keys.CurrentKey(ctx)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := s.backend.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		env, err := s.read(ctx, id)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		if env.KeyID == keyID {
+			continue
+		}
+		dataKey, err := s.unwrap(ctx, env)
+		if err != nil {
+			return n, err
+		}
+		wrapped, err := seal(kek, dataKey, []byte(keyID))
+		if err != nil {
+			return n, err
+		}
+		env.KeyID = keyID
+		env.WrappedKey = wrapped
+		if err := s.write(ctx, id, env); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (s *EncryptedStore) unwrap(ctx context.Context, env *envelope) ([]byte, error) {
+	kek, err := s.keys.Key(ctx, env.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := open(kek, env.WrappedKey, []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("vault: unwrap data key with %q: %w", env.KeyID, err)
+	}
+	return dataKey, nil
+}
+
+func (s *EncryptedStore) read(ctx context.Context, id string) (*envelope, error) {
+	b, err := s.backend.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	env := new(envelope)
+	if err := json.Unmarshal(b, env); err != nil {
+		return nil, err
+	}
+	if env.Version != envelopeVersion {
+		return nil, fmt.Errorf("vault: unsupported envelope version %d", env.Version)
+	}
+	return env, nil
+}
+
+func (s *EncryptedStore) write(ctx context.Context, id string, env *envelope) error {
+	b, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return s.backend.Put(ctx, id, b)
+}
+
+// seal encrypts plaintext with AES-GCM and returns nonce||ciphertext.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open reverses seal.
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,132 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestEncryptedStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileBackend, err := NewFileBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, backend := range map[string]Backend{
+		"memory": NewMemoryBackend(),
+		"file":   fileBackend,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			keys, err := NewStaticKeyProvider("k1", testKey(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			store, err := NewEncryptedStore(backend, keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.Put(ctx, "user/1", "RK0123456789ABC"); err != nil {
+				t.Fatal(err)
+			}
+			blob, err := backend.Get(ctx, "user/1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(blob, []byte("RK0123456789ABC")) {
+				t.Errorf("backend stores plaintext regKey: %s", blob)
+			}
+			got, err := store.Get(ctx, "user/1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != "RK0123456789ABC" {
+				t.Errorf("Get returned %q; want %q", got, "RK0123456789ABC")
+			}
+
+			if err := store.Delete(ctx, "user/1"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Get(ctx, "user/1"); err != ErrNotFound {
+				t.Errorf("Get after Delete returned %v; want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestEncryptedStore_Reencrypt(t *testing.T) {
+	ctx := context.Background()
+	keys, err := NewStaticKeyProvider("k1", testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewEncryptedStore(NewMemoryBackend(), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b"} {
+		if err := store.Put(ctx, id, "regkey-"+id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := keys.Rotate("k2", testKey(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "c", "regkey-c"); err != nil {
+		t.Fatal(err)
+	}
+	n, err := store.Reencrypt(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Reencrypt rewrote %d entries; want 2", n)
+	}
+
+	if err := keys.Retire("k1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		got, err := store.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "regkey-" + id; got != want {
+			t.Errorf("Get(%q) returned %q; want %q", id, got, want)
+		}
+	}
+}
+
+func TestEncryptedStore_BoundToID(t *testing.T) {
+	ctx := context.Background()
+	keys, err := NewStaticKeyProvider("k1", testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := NewMemoryBackend()
+	store, err := NewEncryptedStore(backend, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "alice", "regkey-alice"); err != nil {
+		t.Fatal(err)
+	}
+	blob, _ := backend.Get(ctx, "alice")
+	backend.Put(ctx, "mallory", blob)
+	if _, err := store.Get(ctx, "mallory"); err == nil {
+		t.Error("Get succeeded for a blob copied from another id")
+	}
+}